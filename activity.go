@@ -0,0 +1,116 @@
+package gotabase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var connectionShuttingDownErr = errors.New("database connection is shutting down")
+
+// AbandonedWorkError is returned by Shutdown when some queries or transactions were still running when the connection was force-closed.
+type AbandonedWorkError struct {
+	Queries      int
+	Transactions int
+}
+
+func (e *AbandonedWorkError) Error() string {
+	return fmt.Sprintf("shutdown abandoned %d running queries and %d open transactions", e.Queries, e.Transactions)
+}
+
+// activity keeps track of the work running in a connection pool, so that it can be drained before closing.
+type activity struct {
+	mutex        sync.Mutex
+	closing      bool
+	queries      int
+	transactions int
+	idle         chan struct{}
+}
+
+func (a *activity) begin(transaction bool) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.closing {
+		return connectionShuttingDownErr
+	}
+	if transaction {
+		a.transactions++
+	} else {
+		a.queries++
+	}
+	return nil
+}
+
+func (a *activity) end(transaction bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if transaction {
+		a.transactions--
+	} else {
+		a.queries--
+	}
+	if a.idle != nil && a.queries == 0 && a.transactions == 0 {
+		close(a.idle)
+		a.idle = nil
+	}
+}
+
+// drain stops new work from starting and waits until the running work finishes or the context is done.
+// The amount of work that was still running when waiting stopped is returned.
+func (a *activity) drain(ctx context.Context) (queries int, transactions int) {
+	a.mutex.Lock()
+	a.closing = true
+	if a.queries == 0 && a.transactions == 0 {
+		a.mutex.Unlock()
+		return 0, 0
+	}
+	if a.idle == nil {
+		a.idle = make(chan struct{})
+	}
+	idle := a.idle
+	a.mutex.Unlock()
+
+	select {
+	case <-idle:
+	case <-ctx.Done():
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.queries, a.transactions
+}
+
+// trackedRows marks the query as finished once the rows are exhausted or closed.
+type trackedRows struct {
+	*sql.Rows
+	done func()
+}
+
+func (r *trackedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.done()
+	return false
+}
+
+func (r *trackedRows) Close() error {
+	err := r.Rows.Close()
+	r.done()
+	return err
+}
+
+// trackedRow marks the query as finished once the row is scanned, as that's when database/sql releases its connection.
+type trackedRow struct {
+	*sql.Row
+	done func()
+}
+
+func (r *trackedRow) Scan(dest ...any) error {
+	defer r.done()
+	return r.Row.Scan(dest...)
+}
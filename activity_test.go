@@ -0,0 +1,82 @@
+package gotabase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestActivity_drain(t *testing.T) {
+	t.Run("No running work, drained immediately", func(t *testing.T) {
+		var a activity
+		queries, transactions := a.drain(context.Background())
+		assert.Zero(t, queries)
+		assert.Zero(t, transactions)
+		assert.Equal(t, connectionShuttingDownErr, a.begin(false))
+	})
+	t.Run("Running work finished, drained", func(t *testing.T) {
+		var a activity
+		assert.NoError(t, a.begin(false))
+		assert.NoError(t, a.begin(true))
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			a.end(false)
+			a.end(true)
+		}()
+		queries, transactions := a.drain(context.Background())
+		assert.Zero(t, queries)
+		assert.Zero(t, transactions)
+	})
+	t.Run("Context done, abandoned work reported", func(t *testing.T) {
+		var a activity
+		assert.NoError(t, a.begin(false))
+		assert.NoError(t, a.begin(true))
+		assert.NoError(t, a.begin(true))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		queries, transactions := a.drain(ctx)
+		assert.Equal(t, 1, queries)
+		assert.Equal(t, 2, transactions)
+	})
+}
+
+func TestShutdownNamedConnection(t *testing.T) {
+	t.Run("Running work drained, connection kept registered until closed", func(t *testing.T) {
+		db := openUnpinged(t)
+		connectionsMutex.Lock()
+		connections["shutdown"] = db
+		connectionsMutex.Unlock()
+		assert.NoError(t, db.activity.begin(false))
+
+		done := make(chan error)
+		go func() {
+			done <- ShutdownNamedConnection(context.Background(), "shutdown")
+		}()
+		assert.Eventually(t, func() bool {
+			_, err := GetNamedConnection("shutdown").Exec("select 1")
+			return err == connectionShuttingDownErr
+		}, time.Second, time.Millisecond)
+
+		db.activity.end(false)
+		assert.NoError(t, <-done)
+		assert.Nil(t, getRegisteredConnection("shutdown"))
+	})
+}
+
+func TestDB_QueryRow(t *testing.T) {
+	t.Run("Query tracked until row scanned", func(t *testing.T) {
+		db, err := OpenConnector(&fakeConnector{})
+		assert.NoError(t, err)
+		defer db.Close()
+
+		row, err := db.QueryRow("select 1")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, db.activity.queries)
+
+		var value int
+		assert.NoError(t, row.Scan(&value))
+		assert.Equal(t, 1, value)
+		assert.Zero(t, db.activity.queries)
+	})
+}
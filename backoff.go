@@ -0,0 +1,52 @@
+package gotabase
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultBackoffInitialInterval = 100 * time.Millisecond
+	defaultBackoffMaxInterval     = 10 * time.Second
+	defaultBackoffMultiplier      = 2
+)
+
+// Backoff describes an exponential backoff strategy with optional jitter.
+// Fields left at their zero value fall back to an initial interval of 100ms, a maximum interval of 10s and a multiplier of 2.
+type Backoff struct {
+	// InitialInterval is the delay before the first retry.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between two consecutive attempts.
+	MaxInterval time.Duration
+	// Multiplier is the factor by which the delay grows with every attempt.
+	Multiplier float64
+	// Jitter is the fraction (between 0 and 1) of each delay that is randomised, so that many clients do not retry in lockstep.
+	Jitter float64
+}
+
+// Delay returns the time to wait before the provided retry attempt, counting from 1.
+func (b Backoff) Delay(attempt int) time.Duration {
+	initial := b.InitialInterval
+	if initial <= 0 {
+		initial = defaultBackoffInitialInterval
+	}
+	maxInterval := b.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultBackoffMaxInterval
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultBackoffMultiplier
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := math.Min(float64(initial)*math.Pow(multiplier, float64(attempt-1)), float64(maxInterval))
+	if b.Jitter > 0 {
+		jitter := math.Min(b.Jitter, 1)
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
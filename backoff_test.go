@@ -0,0 +1,32 @@
+package gotabase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	t.Run("Delay grows exponentially", func(t *testing.T) {
+		backoff := Backoff{InitialInterval: time.Second, MaxInterval: time.Minute, Multiplier: 2}
+		assert.Equal(t, time.Second, backoff.Delay(1))
+		assert.Equal(t, 2*time.Second, backoff.Delay(2))
+		assert.Equal(t, 8*time.Second, backoff.Delay(4))
+	})
+	t.Run("Delay capped at max interval", func(t *testing.T) {
+		backoff := Backoff{InitialInterval: time.Second, MaxInterval: 5 * time.Second, Multiplier: 3}
+		assert.Equal(t, 5*time.Second, backoff.Delay(10))
+	})
+	t.Run("Jitter keeps delay within bounds", func(t *testing.T) {
+		backoff := Backoff{InitialInterval: time.Second, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			delay := backoff.Delay(1)
+			assert.LessOrEqual(t, delay, time.Second)
+			assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		}
+	})
+	t.Run("Zero value uses defaults", func(t *testing.T) {
+		assert.Equal(t, defaultBackoffInitialInterval, Backoff{}.Delay(1))
+		assert.Equal(t, defaultBackoffMaxInterval, Backoff{}.Delay(100))
+	})
+}
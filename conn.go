@@ -0,0 +1,66 @@
+package gotabase
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Conn is a Connector pinned to a single physical connection of the pool.
+// It is useful for session-level state, such as session advisory locks, that must be set and cleared on the same connection.
+// The connection must be returned to the pool by calling Close.
+type Conn struct {
+	conn     *sql.Conn
+	finished func()
+}
+
+var _ ContextConnector = (*Conn)(nil)
+
+// Conn pins a single physical connection from the pool.
+func (d *DB) Conn(ctx context.Context) (*Conn, error) {
+	if d == nil {
+		return nil, connectionNotInitialisedErr
+	}
+
+	if err := d.activity.begin(false); err != nil {
+		return nil, err
+	}
+
+	conn, err := d.database.Conn(ctx)
+	if err != nil {
+		d.activity.end(false)
+		return nil, err
+	}
+	return &Conn{conn: conn, finished: sync.OnceFunc(func() { d.activity.end(false) })}, nil
+}
+
+func (c *Conn) QueryRow(sql string, args ...interface{}) (Row, error) {
+	return c.QueryRowContext(context.Background(), sql, args...)
+}
+
+func (c *Conn) QueryRows(sql string, args ...interface{}) (Rows, error) {
+	return c.QueryRowsContext(context.Background(), sql, args...)
+}
+
+func (c *Conn) Exec(sql string, args ...interface{}) (Result, error) {
+	return c.ExecContext(context.Background(), sql, args...)
+}
+
+func (c *Conn) QueryRowContext(ctx context.Context, sql string, args ...interface{}) (Row, error) {
+	result := c.conn.QueryRowContext(ctx, sql, args...)
+	return result, result.Err()
+}
+
+func (c *Conn) QueryRowsContext(ctx context.Context, sql string, args ...interface{}) (Rows, error) {
+	return c.conn.QueryContext(ctx, sql, args...)
+}
+
+func (c *Conn) ExecContext(ctx context.Context, sql string, args ...interface{}) (Result, error) {
+	return c.conn.ExecContext(ctx, sql, args...)
+}
+
+// Close returns the connection to the pool.
+func (c *Conn) Close() error {
+	defer c.finished()
+	return c.conn.Close()
+}
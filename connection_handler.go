@@ -1,104 +1,225 @@
 package gotabase
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"github.com/KowalskiPiotr98/gotabase/logger"
+	"sync"
 )
 
-var connection *connectionHandler
+// DefaultConnectionName is the name under which the connection managed by InitialiseConnection, GetConnection, BeginTransaction and CloseConnection is registered.
+const DefaultConnectionName = "default"
 
-type connectionHandler struct {
-	database *sql.DB
-}
-
-var _ Connector = (*connectionHandler)(nil)
+var (
+	connections      = make(map[string]*DB)
+	connectionsMutex sync.RWMutex
+)
 
 var (
 	connectionNotInitialisedErr  = errors.New("database connection has not been initialised")
 	connectionAlreadyInitialised = errors.New("database connection has already been set")
 )
 
-func (c *connectionHandler) QueryRow(sql string, args ...interface{}) (Row, error) {
-	if connection == nil {
-		return nil, connectionNotInitialisedErr
-	}
+// InitialiseConnection opens the default database connection.
+func InitialiseConnection(connectionString string, driver string, opts ...Option) error {
+	return InitialiseNamedConnection(DefaultConnectionName, connectionString, driver, opts...)
+}
 
-	result := c.database.QueryRow(sql, args...)
-	return result, result.Err()
+// InitialiseNamedConnection opens a database connection and registers it under the provided name.
+// An error is returned if a connection with this name has already been initialised.
+func InitialiseNamedConnection(name string, connectionString string, driver string, opts ...Option) error {
+	return registerConnection(name, func() (*DB, error) {
+		return Open(connectionString, driver, opts...)
+	})
 }
 
-func (c *connectionHandler) QueryRows(sql string, args ...interface{}) (Rows, error) {
-	if connection == nil {
-		return nil, connectionNotInitialisedErr
-	}
+// InitialiseFromDB uses an already opened connection pool as the default database connection.
+func InitialiseFromDB(database *sql.DB, opts ...Option) error {
+	return InitialiseNamedFromDB(DefaultConnectionName, database, opts...)
+}
 
-	return c.database.Query(sql, args...)
+// InitialiseNamedFromDB uses an already opened connection pool as the database connection registered under the provided name.
+// An error is returned if a connection with this name has already been initialised.
+func InitialiseNamedFromDB(name string, database *sql.DB, opts ...Option) error {
+	return registerConnection(name, func() (*DB, error) {
+		return OpenDB(database, opts...)
+	})
 }
 
-func (c *connectionHandler) Exec(sql string, args ...interface{}) (Result, error) {
-	if connection == nil {
-		return nil, connectionNotInitialisedErr
-	}
+// InitialiseFromConnector opens the default database connection using the provided driver connector.
+func InitialiseFromConnector(connector driver.Connector, opts ...Option) error {
+	return InitialiseNamedFromConnector(DefaultConnectionName, connector, opts...)
+}
 
-	return c.database.Exec(sql, args...)
+// InitialiseNamedFromConnector opens a database connection using the provided driver connector and registers it under the provided name.
+// An error is returned if a connection with this name has already been initialised.
+func InitialiseNamedFromConnector(name string, connector driver.Connector, opts ...Option) error {
+	return registerConnection(name, func() (*DB, error) {
+		return OpenConnector(connector, opts...)
+	})
 }
 
-func InitialiseConnection(connectionString string, driver string) error {
-	if connection != nil {
+func registerConnection(name string, open func() (*DB, error)) error {
+	if getRegisteredConnection(name) != nil {
 		return connectionAlreadyInitialised
 	}
 
-	logger.LogInfo("Initialising database connection...")
-	database, err := sql.Open(driver, connectionString)
+	logger.LogInfo("Initialising database connection %s...", name)
+	database, err := open()
 	if err != nil {
-		logger.LogWarn("Failed to open database connection: %v", err)
-		return err
-	}
-	err = database.Ping()
-	if err != nil {
-		logger.LogWarn("Failed to ping database: %v", err)
-		database.Close()
 		return err
 	}
 
-	logger.LogInfo("Database connection established")
-	connection = &connectionHandler{
-		database: database,
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+	if _, exists := connections[name]; exists {
+		// another caller managed to initialise this connection in the meantime
+		if database.owned {
+			database.Close()
+		}
+		return connectionAlreadyInitialised
 	}
+	logger.LogInfo("Database connection %s established", name)
+	connections[name] = database
 	return nil
 }
 
+// GetConnection returns the default database connection.
+// This function panics if the connection has not been initialised.
 func GetConnection() Connector {
-	if connection == nil {
-		logger.LogPanic(connectionNotInitialisedErr.Error())
-	}
+	return GetNamedConnection(DefaultConnectionName)
+}
 
-	return connection
+// GetNamedConnection returns the database connection registered under the provided name.
+// This function panics if the connection has not been initialised.
+func GetNamedConnection(name string) Connector {
+	return getRequiredConnection(name)
+}
+
+// GetDB returns the handle of the default database connection.
+// This function panics if the connection has not been initialised.
+func GetDB() *DB {
+	return GetNamedDB(DefaultConnectionName)
+}
+
+// GetNamedDB returns the handle of the database connection registered under the provided name.
+// This function panics if the connection has not been initialised.
+func GetNamedDB(name string) *DB {
+	return getRequiredConnection(name)
 }
 
+// BeginTransaction starts a new transaction on the default database connection.
+// This function panics if the connection has not been initialised.
 func BeginTransaction() (*Transaction, error) {
-	if connection == nil {
-		logger.LogPanic(connectionNotInitialisedErr.Error())
-	}
+	return BeginNamedTransaction(DefaultConnectionName)
+}
 
-	tx, err := connection.database.Begin()
-	if err != nil {
-		logger.LogWarn("Failed to begin transaction: %v", err)
-		return nil, err
-	}
-	return newTransaction(tx), nil
+// BeginNamedTransaction starts a new transaction on the database connection registered under the provided name.
+// This function panics if the connection has not been initialised.
+func BeginNamedTransaction(name string) (*Transaction, error) {
+	return getRequiredConnection(name).BeginTransaction()
+}
+
+// BeginTransactionWithOptions starts a new transaction with the provided options on the default database connection.
+// This function panics if the connection has not been initialised.
+func BeginTransactionWithOptions(opts TxOptions) (*Transaction, error) {
+	return BeginNamedTransactionWithOptions(DefaultConnectionName, opts)
+}
+
+// BeginNamedTransactionWithOptions starts a new transaction with the provided options on the database connection registered under the provided name.
+// This function panics if the connection has not been initialised.
+func BeginNamedTransactionWithOptions(name string, opts TxOptions) (*Transaction, error) {
+	return getRequiredConnection(name).BeginTransactionWithOptions(opts)
+}
+
+// WithTransaction runs fn in a new transaction on the default database connection.
+// See DB.WithTransaction for details.
+// This function panics if the connection has not been initialised.
+func WithTransaction(fn func(tx *Transaction) error) error {
+	return WithNamedTransaction(DefaultConnectionName, fn)
+}
+
+// WithNamedTransaction runs fn in a new transaction on the database connection registered under the provided name.
+// See DB.WithTransaction for details.
+// This function panics if the connection has not been initialised.
+func WithNamedTransaction(name string, fn func(tx *Transaction) error) error {
+	return getRequiredConnection(name).WithTransaction(fn)
+}
+
+// WithTransactionOptions runs fn in a new transaction with the provided options on the default database connection.
+// See DB.WithTransaction for details.
+// This function panics if the connection has not been initialised.
+func WithTransactionOptions(opts TxOptions, fn func(tx *Transaction) error) error {
+	return WithNamedTransactionOptions(DefaultConnectionName, opts, fn)
 }
 
+// WithNamedTransactionOptions runs fn in a new transaction with the provided options on the database connection registered under the provided name.
+// See DB.WithTransaction for details.
+// This function panics if the connection has not been initialised.
+func WithNamedTransactionOptions(name string, opts TxOptions, fn func(tx *Transaction) error) error {
+	return getRequiredConnection(name).WithTransactionOptions(opts, fn)
+}
+
+// CloseConnection closes the default database connection.
 func CloseConnection() error {
-	if connection == nil {
+	return CloseNamedConnection(DefaultConnectionName)
+}
+
+// CloseNamedConnection closes the database connection registered under the provided name and removes it from the registry.
+// Closing a connection that has not been initialised is a no-op.
+func CloseNamedConnection(name string) error {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+
+	connection, exists := connections[name]
+	if !exists {
 		return nil
 	}
 
-	if err := connection.database.Close(); err != nil {
-		logger.LogWarn("Failed to close database connection: %v", err)
+	if err := connection.Close(); err != nil {
 		return err
 	}
-	connection = nil
+	delete(connections, name)
 	return nil
 }
+
+// Shutdown gracefully closes the default database connection.
+// See DB.Shutdown for details.
+func Shutdown(ctx context.Context) error {
+	return ShutdownNamedConnection(ctx, DefaultConnectionName)
+}
+
+// ShutdownNamedConnection gracefully closes the database connection registered under the provided name and removes it from the registry.
+// The connection stays registered while running work is drained, so that new work is rejected with an error instead of a panic.
+// See DB.Shutdown for details.
+func ShutdownNamedConnection(ctx context.Context, name string) error {
+	connection := getRegisteredConnection(name)
+	if connection == nil {
+		return nil
+	}
+
+	err := connection.Shutdown(ctx)
+
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+	if connections[name] == connection {
+		delete(connections, name)
+	}
+	return err
+}
+
+func getRegisteredConnection(name string) *DB {
+	connectionsMutex.RLock()
+	defer connectionsMutex.RUnlock()
+	return connections[name]
+}
+
+func getRequiredConnection(name string) *DB {
+	connection := getRegisteredConnection(name)
+	if connection == nil {
+		logger.LogPanic(connectionNotInitialisedErr.Error())
+	}
+	return connection
+}
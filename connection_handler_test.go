@@ -0,0 +1,75 @@
+package gotabase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestInitialiseNamedConnection(t *testing.T) {
+	t.Run("Connection initialised, registered under name", func(t *testing.T) {
+		assert.NoError(t, InitialiseNamedConnection("registered", "host=invalid.localhost", "postgres", WithoutPing()))
+		t.Cleanup(func() { _ = CloseNamedConnection("registered") })
+		assert.NotNil(t, GetNamedConnection("registered"))
+	})
+	t.Run("Name already used, error returned", func(t *testing.T) {
+		assert.NoError(t, InitialiseNamedConnection("duplicate", "host=invalid.localhost", "postgres", WithoutPing()))
+		t.Cleanup(func() { _ = CloseNamedConnection("duplicate") })
+		assert.Equal(t, connectionAlreadyInitialised, InitialiseNamedConnection("duplicate", "host=invalid.localhost", "postgres", WithoutPing()))
+	})
+	t.Run("Concurrent initialisation, losing pools closed", func(t *testing.T) {
+		const callers = 5
+		var opened sync.WaitGroup
+		opened.Add(callers)
+		databases := make(chan *DB, callers)
+		errs := make(chan error, callers)
+		for i := 0; i < callers; i++ {
+			go func() {
+				errs <- registerConnection("concurrent", func() (*DB, error) {
+					database, err := Open("host=invalid.localhost", "postgres", WithoutPing())
+					databases <- database
+					// make every caller pass the registry check before any of them registers
+					opened.Done()
+					opened.Wait()
+					return database, err
+				})
+			}()
+		}
+		t.Cleanup(func() { _ = CloseNamedConnection("concurrent") })
+
+		failed := 0
+		for i := 0; i < callers; i++ {
+			if err := <-errs; err != nil {
+				assert.Equal(t, connectionAlreadyInitialised, err)
+				failed++
+			}
+		}
+		assert.Equal(t, callers-1, failed)
+
+		registered := GetNamedConnection("concurrent")
+		close(databases)
+		for database := range databases {
+			if database == registered {
+				continue
+			}
+			assert.ErrorContains(t, database.SqlDB().Ping(), "database is closed")
+		}
+	})
+}
+
+func TestGetNamedConnection(t *testing.T) {
+	t.Run("Connection not initialised, panicked", func(t *testing.T) {
+		assert.Panics(t, func() { GetNamedConnection("missing") })
+	})
+}
+
+func TestCloseNamedConnection(t *testing.T) {
+	t.Run("Connection closed, removed from registry", func(t *testing.T) {
+		assert.NoError(t, InitialiseNamedConnection("closed", "host=invalid.localhost", "postgres", WithoutPing()))
+		assert.NoError(t, CloseNamedConnection("closed"))
+		assert.Nil(t, getRegisteredConnection("closed"))
+	})
+	t.Run("Connection not initialised, nothing done", func(t *testing.T) {
+		assert.NoError(t, CloseNamedConnection("unknown"))
+	})
+}
@@ -1,5 +1,7 @@
 package gotabase
 
+import "context"
+
 // Connector provides an interface for database operations that can be performed during application operation.
 // Returned types such as Row, Rows, and Result provide an abstraction layer over database/sql types, but are compatible with them.
 type Connector interface {
@@ -8,6 +10,40 @@ type Connector interface {
 	Exec(sql string, args ...interface{}) (Result, error)
 }
 
+// ContextConnector is a Connector that passes a context down to the database driver, so that cancellation and deadlines are respected.
+// All connectors provided by this package implement it.
+type ContextConnector interface {
+	Connector
+	QueryRowContext(ctx context.Context, sql string, args ...interface{}) (Row, error)
+	QueryRowsContext(ctx context.Context, sql string, args ...interface{}) (Rows, error)
+	ExecContext(ctx context.Context, sql string, args ...interface{}) (Result, error)
+}
+
+// AsContextConnector returns the provided connector if it implements ContextConnector.
+// Otherwise, it is wrapped so that the context variants call the plain methods, ignoring the context.
+func AsContextConnector(connector Connector) ContextConnector {
+	if contextConnector, ok := connector.(ContextConnector); ok {
+		return contextConnector
+	}
+	return contextFallback{Connector: connector}
+}
+
+type contextFallback struct {
+	Connector
+}
+
+func (c contextFallback) QueryRowContext(_ context.Context, sql string, args ...interface{}) (Row, error) {
+	return c.QueryRow(sql, args...)
+}
+
+func (c contextFallback) QueryRowsContext(_ context.Context, sql string, args ...interface{}) (Rows, error) {
+	return c.QueryRows(sql, args...)
+}
+
+func (c contextFallback) ExecContext(_ context.Context, sql string, args ...interface{}) (Result, error) {
+	return c.Exec(sql, args...)
+}
+
 type Row interface {
 	Scan(dest ...any) error
 }
@@ -0,0 +1,49 @@
+package gotabase
+
+import "context"
+
+type connectorContextKey struct{}
+
+// ContextWithConnector returns a context carrying the provided connector, usually a Transaction.
+// This allows sharing a unit of work across layers without passing the connector around explicitly.
+func ContextWithConnector(ctx context.Context, connector Connector) context.Context {
+	return context.WithValue(ctx, connectorContextKey{}, connector)
+}
+
+// ConnectorFromContext returns the connector stored in the context with ContextWithConnector.
+// If no connector is stored, the default database connection is returned.
+// This function panics if no connector is stored and the default connection has not been initialised.
+func ConnectorFromContext(ctx context.Context) Connector {
+	if connector, ok := ctx.Value(connectorContextKey{}).(Connector); ok && connector != nil {
+		return connector
+	}
+	return GetConnection()
+}
+
+// Resolve returns the connector that should run the work described by the context.
+// If the context carries a Transaction begun on the provided database handle (or on the primary of the provided ReplicaSet), the transaction is returned,
+// so that repositories holding a connection pool still join the unit of work stored with ContextWithConnector.
+// A nil connector resolves to ConnectorFromContext, while any other connector is returned as is.
+func Resolve(ctx context.Context, connector Connector) Connector {
+	if connector == nil {
+		return ConnectorFromContext(ctx)
+	}
+	tx, ok := ctx.Value(connectorContextKey{}).(*Transaction)
+	if !ok || tx == nil {
+		return connector
+	}
+
+	var database *DB
+	switch target := connector.(type) {
+	case *DB:
+		database = target
+	case *ReplicaSet:
+		database = target.primary
+	default:
+		return connector
+	}
+	if database != nil && tx.root().database == database {
+		return tx
+	}
+	return connector
+}
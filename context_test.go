@@ -0,0 +1,53 @@
+package gotabase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConnectorFromContext(t *testing.T) {
+	t.Run("Connector stored, stored connector returned", func(t *testing.T) {
+		db := openUnpinged(t)
+		ctx := ContextWithConnector(context.Background(), db)
+		assert.Same(t, db, ConnectorFromContext(ctx))
+	})
+	t.Run("Nothing stored, default connection returned", func(t *testing.T) {
+		db := openUnpinged(t)
+		connectionsMutex.Lock()
+		connections[DefaultConnectionName] = db
+		connectionsMutex.Unlock()
+		t.Cleanup(func() {
+			connectionsMutex.Lock()
+			delete(connections, DefaultConnectionName)
+			connectionsMutex.Unlock()
+		})
+		assert.Same(t, db, ConnectorFromContext(context.Background()))
+	})
+}
+
+func TestResolve(t *testing.T) {
+	t.Run("Transaction of provided handle stored, transaction returned", func(t *testing.T) {
+		db := openUnpinged(t)
+		tx := &Transaction{database: db}
+		ctx := ContextWithConnector(context.Background(), tx)
+		assert.Same(t, tx, Resolve(ctx, db))
+	})
+	t.Run("Savepoint of provided replica set stored, savepoint returned", func(t *testing.T) {
+		primary, replica := openUnpinged(t), openUnpinged(t)
+		set := NewReplicaSet(primary, []*DB{replica}, RoundRobin)
+		nested := &Transaction{parent: &Transaction{database: primary}}
+		ctx := ContextWithConnector(context.Background(), nested)
+		assert.Same(t, nested, Resolve(ctx, set))
+	})
+	t.Run("Transaction of different handle stored, provided handle returned", func(t *testing.T) {
+		db, other := openUnpinged(t), openUnpinged(t)
+		ctx := ContextWithConnector(context.Background(), &Transaction{database: other})
+		assert.Same(t, db, Resolve(ctx, db))
+	})
+	t.Run("Nil connector, stored connector returned", func(t *testing.T) {
+		tx := &Transaction{}
+		ctx := ContextWithConnector(context.Background(), tx)
+		assert.Same(t, tx, Resolve(ctx, nil))
+	})
+}
@@ -0,0 +1,267 @@
+package gotabase
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"sync"
+	"time"
+)
+
+// DB is a handle to a database connection pool.
+// It is safe for concurrent use and can be used independently of the connections managed by the package level functions.
+type DB struct {
+	database *sql.DB
+	owned    bool
+	activity activity
+
+	leakDetectionAge time.Duration
+}
+
+var _ ContextConnector = (*DB)(nil)
+var _ NestedTransactionBeginner = (*DB)(nil)
+
+// Open opens a new database connection pool and, unless disabled with WithoutPing, verifies it by pinging the database.
+func Open(connectionString string, driverName string, opts ...Option) (*DB, error) {
+	config := newConfig(opts)
+	database, err := sql.Open(driverName, connectionString)
+	if err != nil {
+		logger.LogWarn("Failed to open database connection: %v", err)
+		return nil, err
+	}
+	if len(config.connectHooks) > 0 {
+		connector, err := connectorForDriver(database.Driver(), connectionString)
+		database.Close()
+		if err != nil {
+			logger.LogWarn("Failed to open database connection: %v", err)
+			return nil, err
+		}
+		database = sql.OpenDB(&hookConnector{Connector: connector, hooks: config.connectHooks})
+	}
+	return newDB(database, config, true)
+}
+
+// OpenConnector opens a new database connection pool using the provided driver connector.
+// This allows using connectors that can't be described with a connection string, such as ones using cloud IAM authentication.
+func OpenConnector(connector driver.Connector, opts ...Option) (*DB, error) {
+	config := newConfig(opts)
+	if len(config.connectHooks) > 0 {
+		connector = &hookConnector{Connector: connector, hooks: config.connectHooks}
+	}
+	return newDB(sql.OpenDB(connector), config, true)
+}
+
+// OpenDB wraps an already opened connection pool.
+// Pool settings passed as options are applied to the provided pool.
+// The pool is not closed if the startup ping fails, as it remains owned by the caller until this function succeeds.
+func OpenDB(database *sql.DB, opts ...Option) (*DB, error) {
+	config := newConfig(opts)
+	if len(config.connectHooks) > 0 {
+		return nil, connectHooksUnsupportedErr
+	}
+	return newDB(database, config, false)
+}
+
+func connectorForDriver(d driver.Driver, connectionString string) (driver.Connector, error) {
+	if driverContext, ok := d.(driver.DriverContext); ok {
+		return driverContext.OpenConnector(connectionString)
+	}
+	return &dsnConnector{dsn: connectionString, driver: d}, nil
+}
+
+func newDB(database *sql.DB, config *config, owned bool) (*DB, error) {
+	config.applyPoolSettings(database)
+	err := config.pingDatabase(database)
+	if err != nil {
+		logger.LogWarn("Failed to ping database: %v", err)
+		if owned {
+			database.Close()
+		}
+		return nil, err
+	}
+
+	return &DB{database: database, owned: owned, leakDetectionAge: config.leakDetectionAge}, nil
+}
+
+func (d *DB) QueryRow(sql string, args ...interface{}) (Row, error) {
+	return d.QueryRowContext(context.Background(), sql, args...)
+}
+
+func (d *DB) QueryRows(sql string, args ...interface{}) (Rows, error) {
+	return d.QueryRowsContext(context.Background(), sql, args...)
+}
+
+func (d *DB) Exec(sql string, args ...interface{}) (Result, error) {
+	return d.ExecContext(context.Background(), sql, args...)
+}
+
+func (d *DB) QueryRowContext(ctx context.Context, sql string, args ...interface{}) (Row, error) {
+	if d == nil {
+		return nil, connectionNotInitialisedErr
+	}
+
+	if err := d.activity.begin(false); err != nil {
+		return nil, err
+	}
+
+	result := d.database.QueryRowContext(ctx, sql, args...)
+	if err := result.Err(); err != nil {
+		d.activity.end(false)
+		return result, err
+	}
+	return &trackedRow{Row: result, done: sync.OnceFunc(func() { d.activity.end(false) })}, nil
+}
+
+func (d *DB) QueryRowsContext(ctx context.Context, sql string, args ...interface{}) (Rows, error) {
+	if d == nil {
+		return nil, connectionNotInitialisedErr
+	}
+
+	if err := d.activity.begin(false); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.database.QueryContext(ctx, sql, args...)
+	if err != nil {
+		d.activity.end(false)
+		return nil, err
+	}
+	return &trackedRows{Rows: rows, done: sync.OnceFunc(func() { d.activity.end(false) })}, nil
+}
+
+func (d *DB) ExecContext(ctx context.Context, sql string, args ...interface{}) (Result, error) {
+	if d == nil {
+		return nil, connectionNotInitialisedErr
+	}
+
+	if err := d.activity.begin(false); err != nil {
+		return nil, err
+	}
+	defer d.activity.end(false)
+
+	return d.database.ExecContext(ctx, sql, args...)
+}
+
+// Ping verifies that the database is still reachable.
+func (d *DB) Ping(ctx context.Context) error {
+	if d == nil {
+		return connectionNotInitialisedErr
+	}
+
+	return d.database.PingContext(ctx)
+}
+
+// SqlDB returns the underlying connection pool, for use with libraries that require it.
+func (d *DB) SqlDB() *sql.DB {
+	return d.database
+}
+
+// Stats returns the connection pool statistics.
+func (d *DB) Stats() sql.DBStats {
+	return d.database.Stats()
+}
+
+// BeginTransaction starts a new transaction in this connection pool.
+func (d *DB) BeginTransaction() (*Transaction, error) {
+	return d.BeginTransactionWithOptions(TxOptions{})
+}
+
+// BeginTransactionWithOptions starts a new transaction in this connection pool, using the provided options.
+func (d *DB) BeginTransactionWithOptions(opts TxOptions) (*Transaction, error) {
+	if d == nil {
+		return nil, connectionNotInitialisedErr
+	}
+
+	if err := d.activity.begin(true); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if opts.Timeout > 0 {
+		// the transaction itself is begun without this context, so that the rollback is performed by expire,
+		// rather than concurrently by database/sql
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+	}
+	tx, err := d.database.BeginTx(context.Background(), opts.sqlOptions())
+	if err != nil {
+		cancel()
+		d.activity.end(true)
+		logger.LogWarn("Failed to begin transaction: %v", err)
+		return nil, err
+	}
+	finished := func() {
+		cancel()
+		d.activity.end(true)
+	}
+	if d.leakDetectionAge > 0 {
+		finished = watchForLeak(d.leakDetectionAge, finished)
+	}
+	transaction := newTransaction(tx, finished)
+	transaction.database = d
+	if opts.Timeout > 0 {
+		context.AfterFunc(ctx, transaction.expire)
+	}
+
+	for _, statement := range opts.setupStatements() {
+		if _, err = transaction.Exec(statement); err != nil {
+			logger.LogWarn("Failed to apply transaction options: %v", err)
+			_ = transaction.Rollback()
+			return nil, err
+		}
+	}
+	return transaction, nil
+}
+
+// BeginNested starts a new transaction in this connection pool.
+// See the BeginNested function for details.
+func (d *DB) BeginNested() (*Transaction, error) {
+	return d.BeginTransaction()
+}
+
+// WithTransaction runs fn in a new transaction, which is committed if fn returns nil and rolled back otherwise.
+// If fn panics, the transaction is rolled back and the panic is propagated.
+// If the rollback fails, its error is joined with the one returned by fn.
+func (d *DB) WithTransaction(fn func(tx *Transaction) error) error {
+	return d.WithTransactionOptions(TxOptions{}, fn)
+}
+
+// WithTransactionOptions runs fn in a new transaction started with the provided options.
+// See WithTransaction for details.
+func (d *DB) WithTransactionOptions(opts TxOptions, fn func(tx *Transaction) error) error {
+	tx, err := d.BeginTransactionWithOptions(opts)
+	if err != nil {
+		return err
+	}
+	return runInTransaction(tx, fn)
+}
+
+// Shutdown stops new queries and transactions from starting and waits for the running ones to finish.
+// Once the context is done, the connection pool is closed regardless, and an AbandonedWorkError describing the work that was cut off is returned.
+func (d *DB) Shutdown(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+
+	queries, transactions := d.activity.drain(ctx)
+	var abandonedErr error
+	if queries > 0 || transactions > 0 {
+		abandonedErr = &AbandonedWorkError{Queries: queries, Transactions: transactions}
+		logger.LogWarn("Closing database connection with work still running: %v", abandonedErr)
+	}
+	return errors.Join(abandonedErr, d.Close())
+}
+
+// Close closes the connection pool immediately.
+func (d *DB) Close() error {
+	if d == nil {
+		return nil
+	}
+
+	if err := d.database.Close(); err != nil {
+		logger.LogWarn("Failed to close database connection: %v", err)
+		return err
+	}
+	return nil
+}
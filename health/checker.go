@@ -0,0 +1,201 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"github.com/KowalskiPiotr98/gotabase/migrations"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultInterval = 10 * time.Second
+	defaultTimeout  = 5 * time.Second
+)
+
+var (
+	notCheckedErr        = errors.New("database health has not been checked yet")
+	migrationsPendingErr = errors.New("database migrations have not been applied yet")
+	checkerRunningErr    = errors.New("health checker is already running")
+	checkerNotRunningErr = errors.New("health checker is not running")
+)
+
+// Status describes the result of the latest health check.
+type Status struct {
+	// Alive is true when the latest ping succeeded.
+	Alive bool
+	// Ready is true when the database is alive and all readiness checks passed.
+	Ready bool
+	// LastCheck is the time of the latest check.
+	LastCheck time.Time
+	// LastError is the error returned by the latest failed ping or readiness check.
+	LastError error
+	// Stats are the connection pool statistics collected during the latest check.
+	Stats sql.DBStats
+}
+
+// Checker periodically pings the database and keeps track of its health.
+type Checker struct {
+	database        *gotabase.DB
+	interval        time.Duration
+	timeout         time.Duration
+	readinessChecks []func(ctx context.Context) error
+
+	mutex  sync.RWMutex
+	status Status
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// Option configures a Checker.
+type Option func(checker *Checker)
+
+// WithInterval sets how often the database is checked. Defaults to 10 seconds, which is also used if the interval is not positive.
+func WithInterval(interval time.Duration) Option {
+	return func(checker *Checker) {
+		checker.interval = interval
+	}
+}
+
+// WithTimeout limits the time a single check is allowed to take. Defaults to 5 seconds, which is also used if the timeout is not positive.
+func WithTimeout(timeout time.Duration) Option {
+	return func(checker *Checker) {
+		checker.timeout = timeout
+	}
+}
+
+// WithReadinessCheck adds a custom check that must pass for the database to be reported as ready.
+func WithReadinessCheck(check func(ctx context.Context) error) Option {
+	return func(checker *Checker) {
+		checker.readinessChecks = append(checker.readinessChecks, check)
+	}
+}
+
+// WithMigrations requires all migrations from the provider to be applied for the database to be reported as ready.
+// Once the migrations are found to be up-to-date, they are not checked again.
+func WithMigrations(fileProvider migrations.MigrationFileProvider) Option {
+	return func(checker *Checker) {
+		var applied atomic.Bool
+		checker.readinessChecks = append(checker.readinessChecks, func(ctx context.Context) error {
+			if applied.Load() {
+				return nil
+			}
+			upToDate, err := migrations.IsUpToDateContext(ctx, checker.database, fileProvider)
+			if err != nil {
+				return err
+			}
+			if !upToDate {
+				return migrationsPendingErr
+			}
+			applied.Store(true)
+			return nil
+		})
+	}
+}
+
+// NewChecker creates a new health checker for the provided database.
+// The checker does not run until Start is called.
+func NewChecker(database *gotabase.DB, opts ...Option) *Checker {
+	checker := &Checker{
+		database: database,
+		interval: defaultInterval,
+		timeout:  defaultTimeout,
+		status:   Status{LastError: notCheckedErr},
+	}
+	for _, opt := range opts {
+		opt(checker)
+	}
+	if checker.interval <= 0 {
+		logger.LogWarn("Invalid health check interval %v, using the default of %v", checker.interval, defaultInterval)
+		checker.interval = defaultInterval
+	}
+	if checker.timeout <= 0 {
+		logger.LogWarn("Invalid health check timeout %v, using the default of %v", checker.timeout, defaultTimeout)
+		checker.timeout = defaultTimeout
+	}
+	return checker
+}
+
+// Start runs the first check immediately and then keeps checking the database in the background until Stop is called.
+func (c *Checker) Start() error {
+	c.mutex.Lock()
+	if c.stop != nil {
+		c.mutex.Unlock()
+		return checkerRunningErr
+	}
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	stop, done := c.stop, c.done
+	c.mutex.Unlock()
+
+	c.Check(context.Background())
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.Check(context.Background())
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the background checks and waits for the running check to finish.
+func (c *Checker) Stop() error {
+	c.mutex.Lock()
+	if c.stop == nil {
+		c.mutex.Unlock()
+		return checkerNotRunningErr
+	}
+	stop, done := c.stop, c.done
+	c.stop, c.done = nil, nil
+	c.mutex.Unlock()
+
+	close(stop)
+	<-done
+	return nil
+}
+
+// Check runs a single health check and stores its result.
+func (c *Checker) Check(ctx context.Context) Status {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	status := Status{LastCheck: time.Now()}
+	if err := c.database.Ping(ctx); err != nil {
+		logger.LogWarn("Database health check failed: %v", err)
+		status.LastError = err
+	} else {
+		status.Alive = true
+		status.Ready = true
+		for _, check := range c.readinessChecks {
+			if err = check(ctx); err != nil {
+				status.Ready = false
+				status.LastError = err
+				break
+			}
+		}
+	}
+	status.Stats = c.database.Stats()
+
+	c.mutex.Lock()
+	c.status = status
+	c.mutex.Unlock()
+	return status
+}
+
+// Status returns the result of the latest check.
+func (c *Checker) Status() Status {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.status
+}
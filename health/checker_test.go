@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/internal/tests"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	t.Run("Database reachable, ready and alive", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		checker := NewChecker(db)
+		status := checker.Check(context.Background())
+		assert.True(t, status.Alive)
+		assert.True(t, status.Ready)
+		assert.NoError(t, status.LastError)
+		assertResponseCode(t, checker.ReadinessHandler(), http.StatusOK)
+	})
+	t.Run("Database unreachable, not alive", func(t *testing.T) {
+		db, err := gotabase.Open("host=127.0.0.1 port=1 sslmode=disable", "postgres", gotabase.WithoutPing())
+		tests.PanicOnErr(err)
+		defer db.Close()
+		checker := NewChecker(db)
+		status := checker.Check(context.Background())
+		assert.False(t, status.Alive)
+		assert.Error(t, status.LastError)
+		assertResponseCode(t, checker.LivenessHandler(), http.StatusServiceUnavailable)
+		assertResponseCode(t, checker.ReadinessHandler(), http.StatusServiceUnavailable)
+	})
+	t.Run("Not checked yet, not alive", func(t *testing.T) {
+		checker := NewChecker(nil)
+		assertResponseCode(t, checker.LivenessHandler(), http.StatusServiceUnavailable)
+	})
+	t.Run("Migrations pending, alive but not ready", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		provider := fstest.MapFS{"sql/0.sql": {Data: []byte("create table migrations (id integer primary key);")}}
+		checker := NewChecker(db, WithMigrations(provider))
+		status := checker.Check(context.Background())
+		assert.True(t, status.Alive)
+		assert.False(t, status.Ready)
+		assertResponseCode(t, checker.LivenessHandler(), http.StatusOK)
+		assertResponseCode(t, checker.ReadinessHandler(), http.StatusServiceUnavailable)
+	})
+}
+
+func TestNewChecker(t *testing.T) {
+	t.Run("Interval and timeout not positive, defaults used", func(t *testing.T) {
+		checker := NewChecker(nil, WithInterval(0), WithTimeout(-time.Second))
+		assert.Equal(t, defaultInterval, checker.interval)
+		assert.Equal(t, defaultTimeout, checker.timeout)
+	})
+}
+
+func assertResponseCode(t *testing.T, handler http.Handler, code int) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, code, recorder.Code)
+}
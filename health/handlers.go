@@ -0,0 +1,62 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type response struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	LastCheck time.Time `json:"lastCheck"`
+	Stats     stats     `json:"stats"`
+}
+
+type stats struct {
+	OpenConnections int   `json:"openConnections"`
+	InUse           int   `json:"inUse"`
+	Idle            int   `json:"idle"`
+	WaitCount       int64 `json:"waitCount"`
+}
+
+// LivenessHandler returns a handler (usually mounted as /healthz) that responds with 200 when the latest ping succeeded and 503 otherwise.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := c.Status()
+		writeStatus(w, status, status.Alive)
+	})
+}
+
+// ReadinessHandler returns a handler (usually mounted as /readyz) that responds with 200 when the database is alive and all readiness checks passed, and 503 otherwise.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := c.Status()
+		writeStatus(w, status, status.Ready)
+	})
+}
+
+func writeStatus(w http.ResponseWriter, status Status, ok bool) {
+	body := response{
+		Status:    "ok",
+		LastCheck: status.LastCheck,
+		Stats: stats{
+			OpenConnections: status.Stats.OpenConnections,
+			InUse:           status.Stats.InUse,
+			Idle:            status.Stats.Idle,
+			WaitCount:       status.Stats.WaitCount,
+		},
+	}
+	code := http.StatusOK
+	if !ok {
+		body.Status = "unavailable"
+		code = http.StatusServiceUnavailable
+		if status.LastError != nil {
+			body.Error = status.LastError.Error()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
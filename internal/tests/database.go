@@ -10,32 +10,37 @@ import (
 	"testing"
 )
 
-func GetDatabaseWithCleanup(t *testing.T) gotabase.Connector {
+func GetDatabaseWithCleanup(t *testing.T) *gotabase.DB {
 	db, name := GetDatabase()
-	t.Cleanup(func() { DropDatabase(name) })
+	t.Cleanup(func() {
+		PanicOnErr(db.Close())
+		DropDatabase(name)
+	})
 	return db
 }
 
-func GetDatabase() (gotabase.Connector, string) {
+func GetDatabase() (*gotabase.DB, string) {
 	dbName := strconv.Itoa(rand.Int())
 	baseConnectionString := getBaseConnectionString()
-	err := gotabase.InitialiseConnection(baseConnectionString+dbName, "postgres")
+	db, err := gotabase.Open(baseConnectionString+dbName, "postgres")
 	if err != nil {
-		PanicOnErr(gotabase.InitialiseConnection(baseConnectionString+"postgres", "postgres"))
-		_, err = gotabase.GetConnection().Exec(fmt.Sprintf("create database \"%s\"", dbName))
+		admin, err := gotabase.Open(baseConnectionString+"postgres", "postgres")
+		PanicOnErr(err)
+		_, err = admin.Exec(fmt.Sprintf("create database \"%s\"", dbName))
+		PanicOnErr(err)
+		PanicOnErr(admin.Close())
+		db, err = gotabase.Open(baseConnectionString+dbName, "postgres")
 		PanicOnErr(err)
-		PanicOnErr(gotabase.CloseConnection())
-		PanicOnErr(gotabase.InitialiseConnection(baseConnectionString+dbName, "postgres"))
 	}
-	return gotabase.GetConnection(), dbName
+	return db, dbName
 }
 
 func DropDatabase(dbName string) {
-	PanicOnErr(gotabase.CloseConnection())
-	PanicOnErr(gotabase.InitialiseConnection(getBaseConnectionString()+"postgres", "postgres"))
-	_, err := gotabase.GetConnection().Exec(fmt.Sprintf("drop database \"%s\"", dbName))
+	admin, err := gotabase.Open(getBaseConnectionString()+"postgres", "postgres")
+	PanicOnErr(err)
+	_, err = admin.Exec(fmt.Sprintf("drop database \"%s\"", dbName))
 	PanicOnErr(err)
-	PanicOnErr(gotabase.CloseConnection())
+	PanicOnErr(admin.Close())
 }
 
 func getBaseConnectionString() string {
@@ -0,0 +1,28 @@
+package gotabase
+
+import (
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"runtime/debug"
+	"time"
+)
+
+// WithLeakDetection makes the pool log a warning, including the stack trace of the BeginTransaction call, for every transaction that has been neither committed nor rolled back after maxAge.
+// Capturing the stack trace on every transaction has a cost, so this is mostly meant for debugging.
+func WithLeakDetection(maxAge time.Duration) Option {
+	return func(config *config) {
+		config.leakDetectionAge = maxAge
+	}
+}
+
+// watchForLeak starts a timer warning about the transaction after maxAge.
+// The returned function stops the timer and calls finished.
+func watchForLeak(maxAge time.Duration, finished func()) func() {
+	stack := debug.Stack()
+	timer := time.AfterFunc(maxAge, func() {
+		logger.LogWarn("Transaction has been open for more than %v without being committed or rolled back, it was started at:\n%s", maxAge, stack)
+	})
+	return func() {
+		timer.Stop()
+		finished()
+	}
+}
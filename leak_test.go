@@ -0,0 +1,46 @@
+package gotabase
+
+import (
+	"fmt"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func captureWarnings(t *testing.T) func() []string {
+	var mutex sync.Mutex
+	var warnings []string
+	original := logger.LogWarn
+	logger.LogWarn = func(format string, args ...interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	t.Cleanup(func() { logger.LogWarn = original })
+	return func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return warnings
+	}
+}
+
+func TestWatchForLeak(t *testing.T) {
+	t.Run("Transaction not finished, warning logged with stack", func(t *testing.T) {
+		warnings := captureWarnings(t)
+		finished := watchForLeak(10*time.Millisecond, func() {})
+		defer finished()
+		assert.Eventually(t, func() bool { return len(warnings()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Contains(t, warnings()[0], "leak_test.go")
+	})
+	t.Run("Transaction finished, no warning logged", func(t *testing.T) {
+		warnings := captureWarnings(t)
+		called := false
+		finished := watchForLeak(10*time.Millisecond, func() { called = true })
+		finished()
+		time.Sleep(30 * time.Millisecond)
+		assert.True(t, called)
+		assert.Empty(t, warnings())
+	})
+}
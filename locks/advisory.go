@@ -0,0 +1,141 @@
+// Package locks provides Postgres advisory locks for coordinating independent application instances.
+// The lock statements are kept in package variables, which can be overwritten to use an equivalent mechanism of a different DBMS.
+package locks
+
+import (
+	"context"
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"hash/fnv"
+)
+
+var (
+	// SessionLockSql takes the session-level lock with the key passed as its only argument, waiting until it's available.
+	SessionLockSql = "select pg_advisory_lock($1)"
+	// TrySessionLockSql attempts to take the session-level lock with the provided key, returning whether it has been acquired.
+	TrySessionLockSql = "select pg_try_advisory_lock($1)"
+	// SessionUnlockSql releases the session-level lock with the provided key, returning whether it was held.
+	SessionUnlockSql = "select pg_advisory_unlock($1)"
+	// TransactionLockSql takes the transaction-level lock with the provided key, waiting until it's available.
+	TransactionLockSql = "select pg_advisory_xact_lock($1)"
+	// TryTransactionLockSql attempts to take the transaction-level lock with the provided key, returning whether it has been acquired.
+	TryTransactionLockSql = "select pg_try_advisory_xact_lock($1)"
+)
+
+var unsupportedConnectorErr = errors.New("advisory locks require a transaction or a connector capable of pinning a connection")
+
+// ConnectionPinner is implemented by connectors capable of pinning a single physical connection, such as gotabase.DB.
+type ConnectionPinner interface {
+	Conn(ctx context.Context) (*gotabase.Conn, error)
+}
+
+// Key hashes a string into an advisory lock key.
+// The hash (64-bit FNV-1a) is stable across processes and versions, so it can be used to coordinate independent application instances.
+func Key(name string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
+
+// WithAdvisoryLock runs fn while holding the advisory lock with the provided key, waiting for the lock if it's held by someone else.
+// See WithAdvisoryLockContext for details.
+func WithAdvisoryLock(connector gotabase.Connector, key int64, fn func(connector gotabase.Connector) error) error {
+	return WithAdvisoryLockContext(context.Background(), connector, key, fn)
+}
+
+// WithAdvisoryLockContext runs fn while holding the advisory lock with the provided key, waiting for the lock if it's held by someone else.
+// When the connector is a gotabase.Transaction, a transaction-level lock is taken, which is released when the transaction finishes.
+// Otherwise, a session-level lock is taken on a pinned connection, which is passed to fn and released once fn returns or panics.
+func WithAdvisoryLockContext(ctx context.Context, connector gotabase.Connector, key int64, fn func(connector gotabase.Connector) error) (err error) {
+	_, locked, unlock, err := acquire(ctx, connector, key, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, unlock())
+	}()
+
+	return fn(locked)
+}
+
+// TryAdvisoryLock attempts to take the advisory lock with the provided key without waiting.
+// See TryAdvisoryLockContext for details.
+func TryAdvisoryLock(connector gotabase.Connector, key int64) (bool, gotabase.Connector, func() error, error) {
+	return TryAdvisoryLockContext(context.Background(), connector, key)
+}
+
+// TryAdvisoryLockContext attempts to take the advisory lock with the provided key without waiting.
+// If the lock has been acquired, the connector holding it and a function releasing it are returned.
+// Work that must be done under the lock should use the returned connector, as for session-level locks it is pinned to the connection holding the lock.
+// The lock kind depends on the connector, as described in WithAdvisoryLockContext.
+func TryAdvisoryLockContext(ctx context.Context, connector gotabase.Connector, key int64) (bool, gotabase.Connector, func() error, error) {
+	return acquire(ctx, connector, key, true)
+}
+
+func acquire(ctx context.Context, connector gotabase.Connector, key int64, try bool) (bool, gotabase.Connector, func() error, error) {
+	switch target := connector.(type) {
+	case *gotabase.Transaction:
+		acquired, err := lock(ctx, target, key, try, TransactionLockSql, TryTransactionLockSql)
+		if err != nil || !acquired {
+			return false, nil, nil, err
+		}
+		// transaction-level locks are released together with the transaction
+		return true, target, func() error { return nil }, nil
+	case *gotabase.Conn:
+		acquired, err := lock(ctx, target, key, try, SessionLockSql, TrySessionLockSql)
+		if err != nil || !acquired {
+			return false, nil, nil, err
+		}
+		return true, target, func() error { return unlock(target, key) }, nil
+	case ConnectionPinner:
+		conn, err := target.Conn(ctx)
+		if err != nil {
+			return false, nil, nil, err
+		}
+		acquired, err := lock(ctx, conn, key, try, SessionLockSql, TrySessionLockSql)
+		if err != nil || !acquired {
+			return false, nil, nil, errors.Join(err, conn.Close())
+		}
+		return true, conn, func() error { return errors.Join(unlock(conn, key), conn.Close()) }, nil
+	default:
+		return false, nil, nil, unsupportedConnectorErr
+	}
+}
+
+func lock(ctx context.Context, connector gotabase.ContextConnector, key int64, try bool, lockSql string, tryLockSql string) (bool, error) {
+	if !try {
+		if _, err := connector.ExecContext(ctx, lockSql, key); err != nil {
+			logger.LogWarn("Failed to acquire advisory lock %d: %v", key, err)
+			return false, err
+		}
+		return true, nil
+	}
+
+	row, err := connector.QueryRowContext(ctx, tryLockSql, key)
+	if err != nil {
+		return false, err
+	}
+	var acquired bool
+	if err = row.Scan(&acquired); err != nil {
+		logger.LogWarn("Failed to acquire advisory lock %d: %v", key, err)
+		return false, err
+	}
+	return acquired, nil
+}
+
+func unlock(connector gotabase.Connector, key int64) error {
+	row, err := connector.QueryRow(SessionUnlockSql, key)
+	if err != nil {
+		return err
+	}
+	var released bool
+	if err = row.Scan(&released); err != nil {
+		logger.LogWarn("Failed to release advisory lock %d: %v", key, err)
+		return err
+	}
+	if !released {
+		logger.LogWarn("Advisory lock %d was not held when releasing it", key)
+	}
+	return nil
+}
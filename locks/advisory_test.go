@@ -0,0 +1,64 @@
+package locks
+
+import (
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/internal/tests"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	t.Run("Key is stable", func(t *testing.T) {
+		assert.Equal(t, int64(-3750763034362895579), Key(""))
+		assert.Equal(t, Key("migrations"), Key("migrations"))
+	})
+	t.Run("Different names, different keys", func(t *testing.T) {
+		assert.NotEqual(t, Key("first job"), Key("second job"))
+	})
+}
+
+func TestWithAdvisoryLock(t *testing.T) {
+	t.Run("Session lock held, other session can't acquire it", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		err := WithAdvisoryLock(db, 42, func(connector gotabase.Connector) error {
+			acquired, _, _, err := TryAdvisoryLock(db, 42)
+			assert.False(t, acquired)
+			return err
+		})
+		assert.NoError(t, err)
+		acquired, _, unlock, err := TryAdvisoryLock(db, 42)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.NoError(t, unlock())
+	})
+	t.Run("Transaction lock held, released with transaction", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tx, err := db.BeginTransaction()
+		tests.PanicOnErr(err)
+		err = WithAdvisoryLock(tx, 42, func(connector gotabase.Connector) error {
+			assert.Same(t, tx, connector)
+			return nil
+		})
+		assert.NoError(t, err)
+		acquired, _, _, err := TryAdvisoryLock(db, 42)
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		tests.PanicOnErr(tx.Rollback())
+		acquired, _, unlock, err := TryAdvisoryLock(db, 42)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.NoError(t, unlock())
+	})
+	t.Run("Function panicked, session lock released", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		assert.Panics(t, func() {
+			_ = WithAdvisoryLock(db, 42, func(connector gotabase.Connector) error {
+				panic("failed")
+			})
+		})
+		acquired, _, unlock, err := TryAdvisoryLock(db, 42)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.NoError(t, unlock())
+	})
+}
@@ -0,0 +1,183 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	database "github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"slices"
+)
+
+var (
+	// ChecksumColumnSql adds the checksum column to the migrations table, if it's not there yet.
+	ChecksumColumnSql = "alter table migrations add column if not exists checksum varchar(64)"
+	// ChecksumSelectorSql selects ids and checksums of all applied migrations.
+	ChecksumSelectorSql = "select id, checksum from migrations order by id"
+	// ChecksumUpdateSql stores the checksum of a single applied migration.
+	ChecksumUpdateSql = "update migrations set checksum = '%s' where id = %d"
+	// ChecksumMigrationCreator is used instead of MigrationCreator when checksums are tracked.
+	// The checksum is recorded in the same transaction as the migration itself, after making sure the checksum column exists.
+	ChecksumMigrationCreator = func(migrationBodySql string, currentMigration int, checksum string) string {
+		return fmt.Sprintf("begin transaction;\n"+
+			"%s\n"+
+			"%s;\n"+
+			"insert into migrations (id, checksum) values (%d, '%s');\n"+
+			"commit;",
+			migrationBodySql,
+			ChecksumColumnSql,
+			currentMigration,
+			checksum)
+	}
+)
+
+var checksumMismatchErr = errors.New("applied migrations have been modified")
+
+// WithChecksums enables checksum tracking of applied migrations.
+// Before migrating, checksums stored in the migrations table are compared with the files in the provider.
+// If any of them differ, an error is returned when failOnMismatch is set, or a warning is logged otherwise.
+// Checksums of newly applied migrations are stored together with them (see ChecksumMigrationCreator),
+// while migrations applied before the tracking was enabled get the checksums of their current files.
+// Checksums are neither verified nor stored during a dry run.
+func WithChecksums(failOnMismatch bool) Option {
+	return func(config *config) {
+		config.checksums = true
+		config.failOnChecksumMismatch = failOnMismatch
+	}
+}
+
+// VerifyChecksums compares checksums stored in the migrations table with the files in the provider, and returns ids of migrations that differ.
+// Migrations without a stored checksum, or without a file, are not reported.
+func VerifyChecksums(connector database.Connector, fileProvider MigrationFileProvider) ([]int, error) {
+	stored, err := getStoredChecksums(connector)
+	if err != nil {
+		return nil, err
+	}
+	return compareChecksums(stored, fileProvider), nil
+}
+
+// RepairChecksums stores checksums of the current migration files for all applied migrations, accepting any changes made to them.
+func RepairChecksums(connector database.Connector, fileProvider MigrationFileProvider) error {
+	if err := ensureChecksumColumn(connector); err != nil {
+		return err
+	}
+
+	applied, err := getAppliedMigrations(connector)
+	if err != nil {
+		return err
+	}
+	for _, id := range applied {
+		migrationSql, err := getMigrationSql(fileProvider, id)
+		if err != nil {
+			logger.LogWarn("Migration %d has no file, leaving its checksum unchanged", id)
+			continue
+		}
+		if err = storeChecksum(connector, id, migrationSql); err != nil {
+			return err
+		}
+	}
+	logger.LogInfo("Checksums of %d applied migrations repaired.", len(applied))
+	return nil
+}
+
+// checkChecksums verifies checksums of applied migrations according to the config, and stores the missing ones.
+// Nothing is done if checksum tracking is disabled, during a dry run, or before any migration has been applied.
+func checkChecksums(connector database.Connector, fileProvider MigrationFileProvider, config *config, latestApplied int) error {
+	if !config.checksums || config.dryRun || latestApplied < 0 {
+		return nil
+	}
+	if err := ensureChecksumColumn(connector); err != nil {
+		return err
+	}
+
+	stored, err := getStoredChecksums(connector)
+	if err != nil {
+		return err
+	}
+	for id, storedChecksum := range stored {
+		if storedChecksum.Valid {
+			continue
+		}
+		migrationSql, err := getMigrationSql(fileProvider, id)
+		if err != nil {
+			continue
+		}
+		logger.LogInfo("Storing checksum of migration %d applied before checksum tracking", id)
+		if err = storeChecksum(connector, id, migrationSql); err != nil {
+			return err
+		}
+	}
+
+	mismatched := compareChecksums(stored, fileProvider)
+	if len(mismatched) == 0 {
+		return nil
+	}
+	if config.failOnChecksumMismatch {
+		logger.LogWarn("Applied migrations %v have been modified, refusing to migrate", mismatched)
+		return fmt.Errorf("%w: %v", checksumMismatchErr, mismatched)
+	}
+	logger.LogWarn("Applied migrations %v have been modified", mismatched)
+	return nil
+}
+
+// compareChecksums returns sorted ids of migrations with a stored checksum different from their file.
+func compareChecksums(stored map[int]sql.NullString, fileProvider MigrationFileProvider) []int {
+	mismatched := make([]int, 0)
+	for id, storedChecksum := range stored {
+		if !storedChecksum.Valid {
+			continue
+		}
+		migrationSql, err := getMigrationSql(fileProvider, id)
+		if err != nil {
+			continue
+		}
+		if checksum(migrationSql) != storedChecksum.String {
+			mismatched = append(mismatched, id)
+		}
+	}
+	slices.Sort(mismatched)
+	return mismatched
+}
+
+func ensureChecksumColumn(connector database.Connector) error {
+	if _, err := connector.Exec(ChecksumColumnSql); err != nil {
+		logger.LogWarn("Unable to add checksum column to the migrations table: %v", err)
+		return err
+	}
+	return nil
+}
+
+func storeChecksum(connector database.Connector, id int, migrationSql string) error {
+	if _, err := connector.Exec(fmt.Sprintf(ChecksumUpdateSql, checksum(migrationSql), id)); err != nil {
+		logger.LogWarn("Unable to store checksum of migration %d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+func getStoredChecksums(connector database.Connector) (map[int]sql.NullString, error) {
+	rows, err := connector.QueryRows(ChecksumSelectorSql)
+	if err != nil {
+		logger.LogWarn("Unable to get stored migration checksums: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := make(map[int]sql.NullString)
+	for rows.Next() {
+		var id int
+		var storedChecksum sql.NullString
+		if err = rows.Scan(&id, &storedChecksum); err != nil {
+			return nil, err
+		}
+		stored[id] = storedChecksum
+	}
+	return stored, nil
+}
+
+func checksum(migrationSql string) string {
+	sum := sha256.Sum256([]byte(migrationSql))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompareChecksums(t *testing.T) {
+	provider := fstest.MapFS{
+		"sql/0.sql": {Data: []byte("create table migrations (id integer primary key);")},
+		"sql/1.sql": {Data: []byte("create table edited (id integer);")},
+	}
+
+	t.Run("Unchanged migrations, nothing reported", func(t *testing.T) {
+		stored := map[int]sql.NullString{
+			0: {String: checksum("create table migrations (id integer primary key);"), Valid: true},
+			1: {String: checksum("create table edited (id integer);"), Valid: true},
+		}
+		assert.Empty(t, compareChecksums(stored, provider))
+	})
+	t.Run("Edited migration, mismatch reported", func(t *testing.T) {
+		stored := map[int]sql.NullString{
+			0: {String: checksum("create table migrations (id integer primary key);"), Valid: true},
+			1: {String: checksum("create table original (id integer);"), Valid: true},
+		}
+		assert.Equal(t, []int{1}, compareChecksums(stored, provider))
+	})
+	t.Run("Missing checksum or file, nothing reported", func(t *testing.T) {
+		stored := map[int]sql.NullString{
+			1: {},
+			2: {String: checksum("create table removed (id integer);"), Valid: true},
+		}
+		assert.Empty(t, compareChecksums(stored, provider))
+	})
+}
+
+func TestChecksumMigrationCreator(t *testing.T) {
+	t.Run("Checksum inserted in migration transaction", func(t *testing.T) {
+		migrationSql := ChecksumMigrationCreator("create table first (id integer);", 1, checksum("create table first (id integer);"))
+		assert.Contains(t, migrationSql, ChecksumColumnSql)
+		assert.Contains(t, migrationSql, "insert into migrations (id, checksum) values (1, '"+checksum("create table first (id integer);")+"');\ncommit;")
+	})
+}
@@ -0,0 +1,149 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	database "github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/locks"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+)
+
+// MigrationLockKey is the advisory lock key guarding migration runs.
+// The lock is taken using the SQL variables of the locks package.
+var MigrationLockKey = locks.Key("gotabase_migrations")
+
+var (
+	// MySqlLockName is the name of the MySQL lock guarding migration runs.
+	MySqlLockName = "gotabase_migrations"
+	// MySqlLockSql takes the named lock passed as its only argument, waiting until it's available.
+	MySqlLockSql = "select get_lock(?, -1)"
+	// MySqlUnlockSql releases the named lock passed as its only argument.
+	MySqlUnlockSql = "select release_lock(?)"
+)
+
+// Locker guards migration runs of independent application instances against each other.
+type Locker interface {
+	// WithLock runs fn while holding the lock, passing it the connector holding the lock.
+	// It waits for the lock until it's acquired or the context is done.
+	WithLock(ctx context.Context, connector database.Connector, fn func(connector database.Connector) error) error
+}
+
+// MigrationLocker is the lock used by migration runs without the WithLocker or WithoutLock options.
+// It defaults to the Postgres advisory lock; set it to MySqlLocker or nil once at startup to change the default for all runs.
+var MigrationLocker Locker = AdvisoryLocker{}
+
+var (
+	migrationLockTimeoutErr              = errors.New("timed out waiting for the migration lock")
+	migrationLockInTransactionErr        = errors.New("migration lock can't be held by a transaction, as each migration commits its own transaction")
+	migrationLockUnsupportedConnectorErr = errors.New("migration lock requires a connector capable of pinning a connection, use WithoutLock to migrate without it")
+	migrationLockNotAcquiredErr          = errors.New("migration lock was not acquired")
+)
+
+// AdvisoryLocker takes a Postgres session advisory lock with the MigrationLockKey key.
+type AdvisoryLocker struct{}
+
+// WithLock implements Locker.
+func (AdvisoryLocker) WithLock(ctx context.Context, connector database.Connector, fn func(connector database.Connector) error) error {
+	switch connector.(type) {
+	case *database.Conn, locks.ConnectionPinner:
+		return locks.WithAdvisoryLockContext(ctx, connector, MigrationLockKey, fn)
+	default:
+		return migrationLockUnsupportedConnectorErr
+	}
+}
+
+// MySqlLocker takes a MySQL named lock (see MySqlLockName) on a pinned connection.
+type MySqlLocker struct{}
+
+// WithLock implements Locker.
+func (MySqlLocker) WithLock(ctx context.Context, connector database.Connector, fn func(connector database.Connector) error) (err error) {
+	conn, release, err := pinConnection(ctx, connector)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, release())
+	}()
+
+	if err = queryLock(ctx, conn, MySqlLockSql); err != nil {
+		logger.LogWarn("Failed to acquire migration lock: %v", err)
+		return err
+	}
+	defer func() {
+		if unlockErr := queryLock(context.Background(), conn, MySqlUnlockSql); unlockErr != nil {
+			logger.LogWarn("Failed to release migration lock: %v", unlockErr)
+			err = errors.Join(err, unlockErr)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// queryLock runs one of the MySQL lock statements, which return 1 when they succeed.
+func queryLock(ctx context.Context, conn *database.Conn, lockSql string) error {
+	row, err := conn.QueryRowContext(ctx, lockSql, MySqlLockName)
+	if err != nil {
+		return err
+	}
+	var result sql.NullInt64
+	if err = row.Scan(&result); err != nil {
+		return err
+	}
+	if !result.Valid || result.Int64 != 1 {
+		return migrationLockNotAcquiredErr
+	}
+	return nil
+}
+
+// pinConnection returns a single physical connection of the connector, and a function releasing it.
+func pinConnection(ctx context.Context, connector database.Connector) (*database.Conn, func() error, error) {
+	switch target := connector.(type) {
+	case *database.Conn:
+		return target, func() error { return nil }, nil
+	case locks.ConnectionPinner:
+		conn, err := target.Conn(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn, conn.Close, nil
+	default:
+		return nil, nil, migrationLockUnsupportedConnectorErr
+	}
+}
+
+// withMigrationLock runs fn while holding the migration lock, passing it the connector holding the lock.
+// Transactions are rejected, as the transaction-level lock would be released when the first migration commits.
+// Connectors the locker can't hold the lock on are rejected as well, unless the lock is disabled.
+func withMigrationLock(connector database.Connector, config *config, fn func(connector database.Connector) error) error {
+	if config.locker == nil {
+		return fn(connector)
+	}
+	if _, ok := connector.(*database.Transaction); ok {
+		logger.LogWarn("Unable to hold the migration lock in a transaction, use a database handle or WithoutLock instead")
+		return migrationLockInTransactionErr
+	}
+
+	ctx := context.Background()
+	if config.lockTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.lockTimeout)
+		defer cancel()
+	}
+
+	logger.LogInfo("Acquiring migration lock")
+	locked := false
+	err := config.locker.WithLock(ctx, connector, func(connector database.Connector) error {
+		locked = true
+		return fn(connector)
+	})
+	if errors.Is(err, migrationLockUnsupportedConnectorErr) {
+		logger.LogWarn("Connector is unable to hold the migration lock, use WithoutLock to migrate without it")
+	}
+	if !locked && ctx.Err() != nil {
+		logger.LogWarn("Timed out waiting for the migration lock after %v", config.lockTimeout)
+		return fmt.Errorf("%w: %w", migrationLockTimeoutErr, err)
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	database "github.com/KowalskiPiotr98/gotabase"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// plainConnector is unable to pin a connection.
+type plainConnector struct{}
+
+func (plainConnector) QueryRow(string, ...interface{}) (database.Row, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (plainConnector) QueryRows(string, ...interface{}) (database.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (plainConnector) Exec(string, ...interface{}) (database.Result, error) {
+	return nil, errors.New("not implemented")
+}
+
+type recordingLocker struct {
+	locked bool
+}
+
+func (l *recordingLocker) WithLock(_ context.Context, connector database.Connector, fn func(connector database.Connector) error) error {
+	l.locked = true
+	return fn(connector)
+}
+
+func TestWithMigrationLock(t *testing.T) {
+	t.Run("Connector unable to pin a connection, rejected", func(t *testing.T) {
+		for _, locker := range []Locker{AdvisoryLocker{}, MySqlLocker{}} {
+			called := false
+			err := withMigrationLock(plainConnector{}, newConfig([]Option{WithLocker(locker)}), func(database.Connector) error {
+				called = true
+				return nil
+			})
+			assert.ErrorIs(t, err, migrationLockUnsupportedConnectorErr)
+			assert.False(t, called)
+		}
+	})
+	t.Run("Lock disabled, run without it", func(t *testing.T) {
+		called := false
+		err := withMigrationLock(plainConnector{}, newConfig([]Option{WithoutLock()}), func(database.Connector) error {
+			called = true
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, called)
+	})
+	t.Run("Custom locker, used instead of the default", func(t *testing.T) {
+		locker := &recordingLocker{}
+		err := withMigrationLock(plainConnector{}, newConfig([]Option{WithLocker(locker)}), func(database.Connector) error {
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, locker.locked)
+	})
+	t.Run("Default locker unset, run without it", func(t *testing.T) {
+		defaultLocker := MigrationLocker
+		MigrationLocker = nil
+		t.Cleanup(func() { MigrationLocker = defaultLocker })
+
+		called := false
+		err := withMigrationLock(plainConnector{}, newConfig(nil), func(database.Connector) error {
+			called = true
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, called)
+	})
+}
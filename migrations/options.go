@@ -0,0 +1,54 @@
+package migrations
+
+import "time"
+
+// Option configures a single run of Migrate, MigrateDown or MigrateTo.
+type Option func(config *config)
+
+type config struct {
+	dryRun      bool
+	locker      Locker
+	lockTimeout time.Duration
+
+	checksums              bool
+	failOnChecksumMismatch bool
+}
+
+func newConfig(opts []Option) *config {
+	config := &config{
+		locker: MigrationLocker,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// WithDryRun makes the run only log the SQL that would be executed for each migration, without executing it.
+// The migrations table is only read, so the run can be safely used against a production database.
+func WithDryRun() Option {
+	return func(config *config) {
+		config.dryRun = true
+	}
+}
+
+// WithLockTimeout limits how long a run waits for the migration lock held by another instance.
+// By default, every run takes a database-level lock (see MigrationLocker), so that instances started together apply each migration only once.
+// Without this option, the run waits for the lock indefinitely.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(config *config) {
+		config.lockTimeout = timeout
+	}
+}
+
+// WithLocker makes the run take the provided lock instead of MigrationLocker.
+func WithLocker(locker Locker) Option {
+	return func(config *config) {
+		config.locker = locker
+	}
+}
+
+// WithoutLock disables the migration lock, for databases without a Locker implementation or connectors unable to hold one.
+func WithoutLock() Option {
+	return WithLocker(nil)
+}
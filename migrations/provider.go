@@ -9,14 +9,21 @@ import (
 	"strings"
 )
 
+const downMigrationSuffix = ".down.sql"
+
 var (
-	migrationNotFound = errors.New("migration with this id was not found")
+	migrationNotFound     = errors.New("migration with this id was not found")
+	downMigrationNotFound = errors.New("down migration with this id was not found")
 )
 
+// getLatestAvailableMigration returns the latest migration in the file provider, or -1 if there are no migrations.
 func getLatestAvailableMigration(migrations MigrationFileProvider) (int, error) {
 	available, err := getAvailableMigrations(migrations)
 	if err != nil {
-		return 0, nil
+		return 0, err
+	}
+	if len(*available) == 0 {
+		return -1, nil
 	}
 	return slices.Max(*available), nil
 }
@@ -30,6 +37,15 @@ func getMigrationSql(migrations MigrationFileProvider, i int) (string, error) {
 	return string(fileBytes), nil
 }
 
+func getDownMigrationSql(migrations MigrationFileProvider, i int) (string, error) {
+	fileBytes, err := migrations.ReadFile(fmt.Sprintf("sql/%d%s", i, downMigrationSuffix))
+	if err != nil {
+		logger.LogWarn("Unable to read down migration file: %v", err)
+		return "", downMigrationNotFound
+	}
+	return string(fileBytes), nil
+}
+
 func getAvailableMigrations(migrations MigrationFileProvider) (*[]int, error) {
 	dirContents, err := migrations.ReadDir("sql")
 	if err != nil {
@@ -39,6 +55,9 @@ func getAvailableMigrations(migrations MigrationFileProvider) (*[]int, error) {
 
 	available := make([]int, 0)
 	for _, file := range dirContents {
+		if strings.HasSuffix(file.Name(), downMigrationSuffix) {
+			continue
+		}
 		nameString := strings.TrimPrefix(strings.TrimSuffix(file.Name(), ".sql"), "sql/")
 		nameInt, err := strconv.Atoi(nameString)
 		if err != nil {
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGetAvailableMigrations(t *testing.T) {
+	t.Run("Down migrations skipped", func(t *testing.T) {
+		provider := fstest.MapFS{
+			"sql/0.sql":      {},
+			"sql/1.sql":      {},
+			"sql/1.down.sql": {},
+		}
+		available, err := getAvailableMigrations(provider)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []int{0, 1}, *available)
+	})
+}
+
+func TestGetDownMigrationSql(t *testing.T) {
+	provider := fstest.MapFS{
+		"sql/1.down.sql": {Data: []byte("drop table test;")},
+	}
+
+	t.Run("Down migration read", func(t *testing.T) {
+		migrationSql, err := getDownMigrationSql(provider, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "drop table test;", migrationSql)
+	})
+	t.Run("Down migration missing, error returned", func(t *testing.T) {
+		_, err := getDownMigrationSql(provider, 2)
+		assert.Equal(t, downMigrationNotFound, err)
+	})
+}
+
+func TestGetLatestAvailableMigration(t *testing.T) {
+	t.Run("Latest migration returned", func(t *testing.T) {
+		provider := fstest.MapFS{
+			"sql/0.sql":      {},
+			"sql/1.sql":      {},
+			"sql/1.down.sql": {},
+		}
+		latest, err := getLatestAvailableMigration(provider)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, latest)
+	})
+	t.Run("No migrations, -1 returned", func(t *testing.T) {
+		provider := fstest.MapFS{
+			"sql": {Mode: fs.ModeDir},
+		}
+		latest, err := getLatestAvailableMigration(provider)
+		assert.NoError(t, err)
+		assert.Equal(t, -1, latest)
+	})
+	t.Run("Directory unreadable, error returned", func(t *testing.T) {
+		_, err := getLatestAvailableMigration(fstest.MapFS{})
+		assert.Equal(t, migrationNotFound, err)
+	})
+}
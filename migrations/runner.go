@@ -1,6 +1,7 @@
 package migrations
 
 import (
+	"context"
 	"fmt"
 	database "github.com/KowalskiPiotr98/gotabase"
 	"github.com/KowalskiPiotr98/gotabase/logger"
@@ -16,50 +17,229 @@ var (
 			migrationBodySql,
 			currentMigration)
 	}
+	DownMigrationCreator = func(migrationBodySql string, currentMigration int) string {
+		return fmt.Sprintf("begin transaction;\n"+
+			"delete from migrations where id = %d;\n"+
+			"%s\n"+
+			"commit;",
+			currentMigration,
+			migrationBodySql)
+	}
 	LatestMigrationSelectorSql = "select id from migrations order by id desc limit 1"
 	IsInitialMigrationError    = func(err error) bool {
 		return strings.HasPrefix(err.Error(), "pq: relation") && strings.HasSuffix(err.Error(), "does not exist")
 	}
 )
 
-func Migrate(connector database.Connector, fileProvider MigrationFileProvider) error {
-	latestApplied, err := getLatestAppliedMigration(connector)
+// Migrate applies all pending migrations available in the file provider, in order.
+// Each migration is run together with the insert into the migrations table, using the overridable MigrationCreator.
+// Use WithDryRun to only log the SQL that would be executed.
+// The run is guarded by a database lock, see WithLockTimeout for details.
+func Migrate(connector database.Connector, fileProvider MigrationFileProvider, opts ...Option) error {
+	config := newConfig(opts)
+	return withMigrationLock(connector, config, func(connector database.Connector) error {
+		return migrate(connector, fileProvider, config)
+	})
+}
+
+func migrate(connector database.Connector, fileProvider MigrationFileProvider, config *config) error {
+	latestApplied, err := getCurrentMigration(context.Background(), connector)
 	if err != nil {
-		if !IsInitialMigrationError(err) {
-			logger.LogWarn("Unable to get latest applied migration: %v", err)
-			return err
-		}
-		latestApplied = -1
+		return err
+	}
+	if err = checkChecksums(connector, fileProvider, config, latestApplied); err != nil {
+		return err
 	}
 
 	latestAvailable, err := getLatestAvailableMigration(fileProvider)
+	if err != nil {
+		return err
+	}
 	logger.LogInfo("Latest applied migration: %d, latest available migration: %d", latestApplied, latestAvailable)
 	if latestApplied == latestAvailable {
 		logger.LogInfo("Latest migration is already applied, nothing to do.")
 		return nil
 	}
 
-	currentMigration := latestApplied + 1
-	for currentMigration <= latestAvailable {
-		migrationSql, err := getMigrationSql(fileProvider, currentMigration)
+	if err = applyMigrations(connector, fileProvider, config, latestApplied, latestAvailable); err != nil {
+		return err
+	}
+	logFinished(config, "All pending migrations applied.")
+	return nil
+}
+
+// MigrateDown reverts applied migrations in reverse order, until the target migration is the latest one applied.
+// Each migration is reverted using its sql/N.down.sql file, and its row is removed from the migrations table.
+// If a down migration file is missing, no further migrations are reverted and an error is returned.
+// Use -1 as the target to revert all migrations.
+func MigrateDown(connector database.Connector, fileProvider MigrationFileProvider, target int, opts ...Option) error {
+	config := newConfig(opts)
+	return withMigrationLock(connector, config, func(connector database.Connector) error {
+		return migrateDown(connector, fileProvider, config, target)
+	})
+}
+
+func migrateDown(connector database.Connector, fileProvider MigrationFileProvider, config *config, target int) error {
+	latestApplied, err := getCurrentMigration(context.Background(), connector)
+	if err != nil {
+		return err
+	}
+
+	logger.LogInfo("Latest applied migration: %d, target migration: %d", latestApplied, target)
+	if latestApplied <= target {
+		logger.LogInfo("Target migration is already the latest one applied, nothing to revert.")
+		return nil
+	}
+
+	if err = revertMigrations(connector, fileProvider, config, latestApplied, target); err != nil {
+		return err
+	}
+	logFinished(config, "Migrations reverted to %d.", target)
+	return nil
+}
+
+// MigrateTo applies or reverts migrations, so that the provided version becomes the latest one applied.
+// Migrations above the target version are left pending, and applied ones above it are reverted using down migrations, as in MigrateDown.
+// An error is returned if the target version is not available in the file provider.
+func MigrateTo(connector database.Connector, fileProvider MigrationFileProvider, version int, opts ...Option) error {
+	config := newConfig(opts)
+	return withMigrationLock(connector, config, func(connector database.Connector) error {
+		return migrateTo(connector, fileProvider, config, version)
+	})
+}
+
+func migrateTo(connector database.Connector, fileProvider MigrationFileProvider, config *config, version int) error {
+	latestApplied, err := getCurrentMigration(context.Background(), connector)
+	if err != nil {
+		return err
+	}
+
+	latestAvailable, err := getLatestAvailableMigration(fileProvider)
+	if err != nil {
+		return err
+	}
+	if version < -1 || version > latestAvailable {
+		logger.LogWarn("Target migration %d is not available, latest available migration: %d", version, latestAvailable)
+		return migrationNotFound
+	}
+
+	if err = checkChecksums(connector, fileProvider, config, latestApplied); err != nil {
+		return err
+	}
+
+	logger.LogInfo("Latest applied migration: %d, target migration: %d", latestApplied, version)
+	switch {
+	case latestApplied < version:
+		err = applyMigrations(connector, fileProvider, config, latestApplied, version)
+	case latestApplied > version:
+		err = revertMigrations(connector, fileProvider, config, latestApplied, version)
+	default:
+		logger.LogInfo("Target migration is already the latest one applied, nothing to do.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logFinished(config, "Migrated to %d.", version)
+	return nil
+}
+
+// IsUpToDate checks whether all migrations available in the file provider have been applied.
+func IsUpToDate(connector database.Connector, fileProvider MigrationFileProvider) (bool, error) {
+	return IsUpToDateContext(context.Background(), connector, fileProvider)
+}
+
+// IsUpToDateContext checks whether all migrations available in the file provider have been applied.
+// The context is passed down to the query reading the migrations table.
+func IsUpToDateContext(ctx context.Context, connector database.Connector, fileProvider MigrationFileProvider) (bool, error) {
+	latestApplied, err := getCurrentMigration(ctx, connector)
+	if err != nil {
+		return false, err
+	}
+
+	latestAvailable, err := getLatestAvailableMigration(fileProvider)
+	if err != nil {
+		return false, err
+	}
+	return latestApplied >= latestAvailable, nil
+}
+
+// applyMigrations applies migrations after latestApplied, up to and including target.
+func applyMigrations(connector database.Connector, fileProvider MigrationFileProvider, config *config, latestApplied, target int) error {
+	for currentMigration := latestApplied + 1; currentMigration <= target; currentMigration++ {
+		migrationBodySql, err := getMigrationSql(fileProvider, currentMigration)
 		if err != nil {
 			return err
 		}
 
+		migrationSql := MigrationCreator(migrationBodySql, currentMigration)
+		if config.checksums {
+			migrationSql = ChecksumMigrationCreator(migrationBodySql, currentMigration, checksum(migrationBodySql))
+		}
+		if config.dryRun {
+			logger.LogInfo("Dry run, migration %d would execute:\n%s", currentMigration, migrationSql)
+			continue
+		}
+
 		logger.LogInfo("Applying migration %d", currentMigration)
-		_, err = connector.Exec(MigrationCreator(migrationSql, currentMigration))
+		_, err = connector.Exec(migrationSql)
 		if err != nil {
 			logger.LogWarn("Unable to execute migration %d: %v", currentMigration, err)
 			return err
 		}
-		currentMigration++
 	}
-	logger.LogInfo("All pending migrations applied.")
 	return nil
 }
 
-func getLatestAppliedMigration(connector database.Connector) (int, error) {
-	result, err := connector.QueryRow(LatestMigrationSelectorSql)
+// revertMigrations reverts migrations from latestApplied down to, but excluding, target.
+func revertMigrations(connector database.Connector, fileProvider MigrationFileProvider, config *config, latestApplied, target int) error {
+	for currentMigration := latestApplied; currentMigration > target; currentMigration-- {
+		migrationSql, err := getDownMigrationSql(fileProvider, currentMigration)
+		if err != nil {
+			logger.LogWarn("Down migration %d is missing, refusing to revert further", currentMigration)
+			return err
+		}
+
+		migrationSql = DownMigrationCreator(migrationSql, currentMigration)
+		if config.dryRun {
+			logger.LogInfo("Dry run, reverting migration %d would execute:\n%s", currentMigration, migrationSql)
+			continue
+		}
+
+		logger.LogInfo("Reverting migration %d", currentMigration)
+		_, err = connector.Exec(migrationSql)
+		if err != nil {
+			logger.LogWarn("Unable to revert migration %d: %v", currentMigration, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// logFinished logs the message describing a finished run, unless it was a dry run, which is reported as such instead.
+func logFinished(config *config, format string, args ...any) {
+	if config.dryRun {
+		logger.LogInfo("Dry run finished, no migrations have been executed.")
+		return
+	}
+	logger.LogInfo(format, args...)
+}
+
+// getCurrentMigration returns the latest applied migration, or -1 if no migrations have been applied yet.
+func getCurrentMigration(ctx context.Context, connector database.Connector) (int, error) {
+	latestApplied, err := getLatestAppliedMigration(ctx, connector)
+	if err != nil {
+		if !IsInitialMigrationError(err) {
+			logger.LogWarn("Unable to get latest applied migration: %v", err)
+			return 0, err
+		}
+		return -1, nil
+	}
+	return latestApplied, nil
+}
+
+func getLatestAppliedMigration(ctx context.Context, connector database.Connector) (int, error) {
+	result, err := database.AsContextConnector(connector).QueryRowContext(ctx, LatestMigrationSelectorSql)
 	if err != nil {
 		return 0, err
 	}
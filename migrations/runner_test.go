@@ -0,0 +1,193 @@
+package migrations
+
+import (
+	"context"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/internal/tests"
+	"github.com/KowalskiPiotr98/gotabase/locks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testMigrations = fstest.MapFS{
+	"sql/0.sql":      {Data: []byte("create table migrations (id integer primary key);")},
+	"sql/1.sql":      {Data: []byte("create table first (id integer);")},
+	"sql/1.down.sql": {Data: []byte("drop table first;")},
+	"sql/2.sql":      {Data: []byte("create table second (id integer);")},
+	"sql/2.down.sql": {Data: []byte("drop table second;")},
+}
+
+func assertCurrentMigration(t *testing.T, connector gotabase.Connector, expected int) {
+	current, err := getCurrentMigration(context.Background(), connector)
+	tests.PanicOnErr(err)
+	assert.Equal(t, expected, current)
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("All migrations applied", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		assert.NoError(t, Migrate(db, testMigrations))
+		assertCurrentMigration(t, db, 2)
+	})
+}
+
+func TestMigrateDown(t *testing.T) {
+	t.Run("Migrations reverted to target", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(Migrate(db, testMigrations))
+		assert.NoError(t, MigrateDown(db, testMigrations, 0))
+		assertCurrentMigration(t, db, 0)
+		_, err := db.Exec("select * from first")
+		assert.Error(t, err)
+	})
+	t.Run("Down migration missing, reverting stopped", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(Migrate(db, testMigrations))
+		assert.Equal(t, downMigrationNotFound, MigrateDown(db, testMigrations, -1))
+		assertCurrentMigration(t, db, 0)
+	})
+}
+
+func TestMigrateTo(t *testing.T) {
+	t.Run("Migrations applied up to target", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		assert.NoError(t, MigrateTo(db, testMigrations, 1))
+		assertCurrentMigration(t, db, 1)
+		_, err := db.Exec("select * from second")
+		assert.Error(t, err)
+	})
+	t.Run("Migrations reverted down to target", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(Migrate(db, testMigrations))
+		assert.NoError(t, MigrateTo(db, testMigrations, 1))
+		assertCurrentMigration(t, db, 1)
+	})
+	t.Run("Target not available, error returned", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		assert.Equal(t, migrationNotFound, MigrateTo(db, testMigrations, 3))
+		assertCurrentMigration(t, db, -1)
+	})
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	t.Run("Dry run, nothing applied", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(MigrateTo(db, testMigrations, 0))
+		assert.NoError(t, Migrate(db, testMigrations, WithDryRun()))
+		assertCurrentMigration(t, db, 0)
+	})
+	t.Run("Dry run, nothing reverted", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(Migrate(db, testMigrations))
+		assert.NoError(t, MigrateDown(db, testMigrations, 0, WithDryRun()))
+		assertCurrentMigration(t, db, 2)
+	})
+}
+
+func TestStatus(t *testing.T) {
+	t.Run("Status read from database", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(MigrateTo(db, testMigrations, 1))
+		status, err := Status(db, testMigrations)
+		assert.NoError(t, err)
+		assert.Equal(t, []MigrationStatus{
+			{Id: 0, State: MigrationApplied},
+			{Id: 1, State: MigrationApplied},
+			{Id: 2, State: MigrationPending},
+		}, status)
+	})
+	t.Run("No migrations table, all pending", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		status, err := Status(db, testMigrations)
+		assert.NoError(t, err)
+		assert.Len(t, status, 3)
+		assert.Equal(t, MigrationPending, status[0].State)
+	})
+}
+
+func TestMigrateWithChecksums(t *testing.T) {
+	editedMigrations := fstest.MapFS{
+		"sql/0.sql": testMigrations["sql/0.sql"],
+		"sql/1.sql": {Data: []byte("create table first (id integer, edited integer);")},
+		"sql/2.sql": testMigrations["sql/2.sql"],
+	}
+
+	t.Run("Edited migration, error returned", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(MigrateTo(db, testMigrations, 1, WithChecksums(true)))
+		assert.ErrorIs(t, Migrate(db, editedMigrations, WithChecksums(true)), checksumMismatchErr)
+		assertCurrentMigration(t, db, 1)
+	})
+	t.Run("Edited migration, only warned", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(MigrateTo(db, testMigrations, 1, WithChecksums(true)))
+		assert.NoError(t, Migrate(db, editedMigrations, WithChecksums(false)))
+		assertCurrentMigration(t, db, 2)
+	})
+	t.Run("Checksums repaired, migration allowed", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(MigrateTo(db, testMigrations, 1, WithChecksums(true)))
+		assert.NoError(t, RepairChecksums(db, editedMigrations))
+		mismatched, err := VerifyChecksums(db, editedMigrations)
+		assert.NoError(t, err)
+		assert.Empty(t, mismatched)
+		assert.NoError(t, Migrate(db, editedMigrations, WithChecksums(true)))
+	})
+	t.Run("Migrations applied before tracking, checksums stored", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tests.PanicOnErr(MigrateTo(db, testMigrations, 1))
+		assert.NoError(t, Migrate(db, testMigrations, WithChecksums(true)))
+		mismatched, err := VerifyChecksums(db, editedMigrations)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1}, mismatched)
+	})
+}
+
+func TestMigrateLock(t *testing.T) {
+	t.Run("Concurrent runs, each migration applied once", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		errs := make(chan error, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				errs <- Migrate(db, testMigrations)
+			}()
+		}
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, <-errs)
+		}
+		assertCurrentMigration(t, db, 2)
+	})
+	t.Run("Lock held elsewhere, timed out", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		err := locks.WithAdvisoryLock(db, MigrationLockKey, func(gotabase.Connector) error {
+			return Migrate(db, testMigrations, WithLockTimeout(100*time.Millisecond))
+		})
+		assert.ErrorIs(t, err, migrationLockTimeoutErr)
+		assertCurrentMigration(t, db, -1)
+	})
+	t.Run("Concurrent runs in transactions, rejected", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		errs := make(chan error, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				errs <- db.WithTransaction(func(tx *gotabase.Transaction) error {
+					return Migrate(tx, testMigrations)
+				})
+			}()
+		}
+		for i := 0; i < 3; i++ {
+			assert.ErrorIs(t, <-errs, migrationLockInTransactionErr)
+		}
+		assertCurrentMigration(t, db, -1)
+	})
+	t.Run("Lock disabled, migrated while lock held elsewhere", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		err := locks.WithAdvisoryLock(db, MigrationLockKey, func(gotabase.Connector) error {
+			return Migrate(db, testMigrations, WithoutLock())
+		})
+		assert.NoError(t, err)
+		assertCurrentMigration(t, db, 2)
+	})
+}
@@ -0,0 +1,114 @@
+package migrations
+
+import (
+	"fmt"
+	database "github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"slices"
+)
+
+// AppliedMigrationsSelectorSql selects ids of all applied migrations.
+var AppliedMigrationsSelectorSql = "select id from migrations order by id"
+
+// MigrationState describes whether a migration has been applied to the database.
+type MigrationState int
+
+const (
+	// MigrationApplied means that the migration file exists and the migration is recorded in the migrations table.
+	MigrationApplied MigrationState = iota
+	// MigrationPending means that the migration file exists and will be applied by the next Migrate call.
+	MigrationPending
+	// MigrationMissingFile means that there is no file for this migration, even though it is recorded in the migrations table or later migrations exist.
+	MigrationMissingFile
+	// MigrationUnknownInDb means that the migration file exists, but the migration is not recorded in the migrations table even though later ones are.
+	// Migrate will never apply such migration.
+	MigrationUnknownInDb
+)
+
+func (s MigrationState) String() string {
+	switch s {
+	case MigrationApplied:
+		return "applied"
+	case MigrationPending:
+		return "pending"
+	case MigrationMissingFile:
+		return "missing file"
+	case MigrationUnknownInDb:
+		return "unknown in database"
+	default:
+		return fmt.Sprintf("unknown (%d)", int(s))
+	}
+}
+
+// MigrationStatus describes the state of a single migration.
+type MigrationStatus struct {
+	Id    int
+	State MigrationState
+}
+
+// Status returns the state of every migration, either available in the file provider or recorded in the migrations table, ordered by id.
+// Nothing is executed against the database apart from reading the migrations table.
+func Status(connector database.Connector, fileProvider MigrationFileProvider) ([]MigrationStatus, error) {
+	available, err := getAvailableMigrations(fileProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := getAppliedMigrations(connector)
+	if err != nil {
+		return nil, err
+	}
+	return buildStatus(*available, applied), nil
+}
+
+func buildStatus(available, applied []int) []MigrationStatus {
+	latest, latestApplied := -1, -1
+	if len(available) > 0 {
+		latest = slices.Max(available)
+	}
+	if len(applied) > 0 {
+		latestApplied = slices.Max(applied)
+		latest = max(latest, latestApplied)
+	}
+
+	status := make([]MigrationStatus, 0, latest+1)
+	for id := 0; id <= latest; id++ {
+		isAvailable, isApplied := slices.Contains(available, id), slices.Contains(applied, id)
+		var state MigrationState
+		switch {
+		case !isAvailable:
+			state = MigrationMissingFile
+		case isApplied:
+			state = MigrationApplied
+		case id < latestApplied:
+			state = MigrationUnknownInDb
+		default:
+			state = MigrationPending
+		}
+		status = append(status, MigrationStatus{Id: id, State: state})
+	}
+	return status
+}
+
+// getAppliedMigrations returns ids of all applied migrations, or an empty slice if the migrations table does not exist yet.
+func getAppliedMigrations(connector database.Connector) ([]int, error) {
+	applied := make([]int, 0)
+	rows, err := connector.QueryRows(AppliedMigrationsSelectorSql)
+	if err != nil {
+		if IsInitialMigrationError(err) {
+			return applied, nil
+		}
+		logger.LogWarn("Unable to get applied migrations: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		applied = append(applied, id)
+	}
+	return applied, nil
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuildStatus(t *testing.T) {
+	t.Run("Applied and pending migrations", func(t *testing.T) {
+		status := buildStatus([]int{0, 1, 2}, []int{0, 1})
+		assert.Equal(t, []MigrationStatus{
+			{Id: 0, State: MigrationApplied},
+			{Id: 1, State: MigrationApplied},
+			{Id: 2, State: MigrationPending},
+		}, status)
+	})
+	t.Run("Applied migration without file, missing file reported", func(t *testing.T) {
+		status := buildStatus([]int{0}, []int{0, 1})
+		assert.Equal(t, []MigrationStatus{
+			{Id: 0, State: MigrationApplied},
+			{Id: 1, State: MigrationMissingFile},
+		}, status)
+	})
+	t.Run("Gap in migration files, missing file reported", func(t *testing.T) {
+		status := buildStatus([]int{0, 2}, []int{0})
+		assert.Equal(t, MigrationMissingFile, status[1].State)
+		assert.Equal(t, MigrationPending, status[2].State)
+	})
+	t.Run("Skipped migration, unknown in database reported", func(t *testing.T) {
+		status := buildStatus([]int{0, 1, 2}, []int{0, 2})
+		assert.Equal(t, MigrationUnknownInDb, status[1].State)
+	})
+	t.Run("Nothing applied, all pending", func(t *testing.T) {
+		status := buildStatus([]int{0, 1}, []int{})
+		assert.Equal(t, []MigrationStatus{
+			{Id: 0, State: MigrationPending},
+			{Id: 1, State: MigrationPending},
+		}, status)
+	})
+}
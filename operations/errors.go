@@ -18,17 +18,23 @@ type errorConfig struct {
 	DataUsedErr error
 	// RowNumberUnexpectedErr indicates that an unexpected number of rows was affected and you should probably consider aborting the transation.
 	RowNumberUnexpectedErr error
+	// SerializationFailureErr indicates that the transaction could not be serialised with concurrent transactions and should be retried.
+	SerializationFailureErr error
+	// DeadlockErr indicates that the transaction was aborted to resolve a deadlock and should be retried.
+	DeadlockErr error
 
 	handlers []func(err error) error
 }
 
 func init() {
 	Errors = errorConfig{
-		DataNotFoundErr:        errors.New("requested data was not found in the database"),
-		DataAlreadyExistErr:    errors.New("this data already exists in the database"),
-		DataUsedErr:            errors.New("this data is being used in another object and cannot be removed"),
-		RowNumberUnexpectedErr: errors.New("unexpected number of rows affected"),
-		handlers:               make([]func(err error) error, 0),
+		DataNotFoundErr:         errors.New("requested data was not found in the database"),
+		DataAlreadyExistErr:     errors.New("this data already exists in the database"),
+		DataUsedErr:             errors.New("this data is being used in another object and cannot be removed"),
+		RowNumberUnexpectedErr:  errors.New("unexpected number of rows affected"),
+		SerializationFailureErr: errors.New("transaction could not be serialised with concurrent transactions"),
+		DeadlockErr:             errors.New("transaction was aborted due to a deadlock"),
+		handlers:                make([]func(err error) error, 0),
 	}
 }
 
@@ -57,6 +63,29 @@ func (config *errorConfig) HandleError(err error) error {
 	return err
 }
 
+// IsRetryable checks whether the error means that the whole transaction should be retried, such as a serialisation failure or a deadlock.
+// Both already handled errors and raw database errors recognised by the registered handlers are classified.
+func (config *errorConfig) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if config.isRetryableErr(err) {
+		return true
+	}
+
+	for _, handler := range config.handlers {
+		handled := handler(err)
+		if handled != nil {
+			return config.isRetryableErr(handled)
+		}
+	}
+	return false
+}
+
+func (config *errorConfig) isRetryableErr(err error) bool {
+	return errors.Is(err, config.SerializationFailureErr) || errors.Is(err, config.DeadlockErr)
+}
+
 func (config *errorConfig) RegisterDefaultPostgresHandlers() {
 	config.RegisterHandler(func(err error) error {
 		// this handles scans of 0 rows
@@ -81,4 +110,16 @@ func (config *errorConfig) RegisterDefaultPostgresHandlers() {
 		}
 		return nil
 	})
+	config.RegisterHandler(func(err error) error {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "40001":
+				return config.SerializationFailureErr
+			case "40P01":
+				return config.DeadlockErr
+			}
+		}
+		return nil
+	})
 }
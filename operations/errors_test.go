@@ -3,6 +3,7 @@ package operations
 import (
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/KowalskiPiotr98/gotabase/internal/tests"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -56,3 +57,20 @@ func TestErrorConfig_HandleError(t *testing.T) {
 		})
 	})
 }
+
+func TestErrorConfig_IsRetryable(t *testing.T) {
+	setPostgresHandlers()
+
+	t.Run("Serialization failure is retryable", func(t *testing.T) {
+		assert.True(t, Errors.IsRetryable(&pq.Error{Code: "40001"}))
+	})
+	t.Run("Deadlock is retryable", func(t *testing.T) {
+		assert.True(t, Errors.IsRetryable(&pq.Error{Code: "40P01"}))
+	})
+	t.Run("Handled error is retryable", func(t *testing.T) {
+		assert.True(t, Errors.IsRetryable(Errors.SerializationFailureErr))
+	})
+	t.Run("Unique violation is not retryable", func(t *testing.T) {
+		assert.False(t, Errors.IsRetryable(&pq.Error{Code: "23505"}))
+	})
+}
@@ -1,6 +1,7 @@
 package operations
 
 import (
+	"context"
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/KowalskiPiotr98/gotabase/logger"
 )
@@ -11,7 +12,12 @@ type HasIdSetter[T any] interface {
 
 // QueryRows is a helper function to run a multiple rows query based on a query string.
 func QueryRows[T any](connector gotabase.Connector, scanner func(row gotabase.Row) (*T, error), query string, args ...any) ([]*T, error) {
-	rows, err := connector.QueryRows(query, args...)
+	return QueryRowsContext(context.Background(), connector, scanner, query, args...)
+}
+
+// QueryRowsContext is a helper function to run a multiple rows query based on a query string.
+func QueryRowsContext[T any](ctx context.Context, connector gotabase.Connector, scanner func(row gotabase.Row) (*T, error), query string, args ...any) ([]*T, error) {
+	rows, err := resolveConnector(ctx, connector).QueryRowsContext(ctx, query, args...)
 	if err != nil {
 		return nil, Errors.HandleError(err)
 	}
@@ -20,7 +26,12 @@ func QueryRows[T any](connector gotabase.Connector, scanner func(row gotabase.Ro
 
 // QueryRow is a helper function to run a single row query.
 func QueryRow[T any](connector gotabase.Connector, scanner func(row gotabase.Row) (*T, error), query string, args ...any) (*T, error) {
-	row, err := connector.QueryRow(query, args...)
+	return QueryRowContext(context.Background(), connector, scanner, query, args...)
+}
+
+// QueryRowContext is a helper function to run a single row query.
+func QueryRowContext[T any](ctx context.Context, connector gotabase.Connector, scanner func(row gotabase.Row) (*T, error), query string, args ...any) (*T, error) {
+	row, err := resolveConnector(ctx, connector).QueryRowContext(ctx, query, args...)
 	if err != nil {
 		return nil, Errors.HandleError(err)
 	}
@@ -35,7 +46,13 @@ func QueryRow[T any](connector gotabase.Connector, scanner func(row gotabase.Row
 // CreateRowWithId creates a new data in the database.
 // The query is expected to return a new id, that will be set in the object using the HasIdSetter interface method.
 func CreateRowWithId[TId any, T HasIdSetter[TId]](connector gotabase.Connector, object T, query string, args ...any) error {
-	return CreateRowWithScan(connector, object, func(row gotabase.Row, object T) error {
+	return CreateRowWithIdContext[TId](context.Background(), connector, object, query, args...)
+}
+
+// CreateRowWithIdContext creates a new data in the database.
+// The query is expected to return a new id, that will be set in the object using the HasIdSetter interface method.
+func CreateRowWithIdContext[TId any, T HasIdSetter[TId]](ctx context.Context, connector gotabase.Connector, object T, query string, args ...any) error {
+	return CreateRowWithScanContext(ctx, connector, object, func(row gotabase.Row, object T) error {
 		var id TId
 		if err := row.Scan(&id); err != nil {
 			logger.LogWarn("Failed to scan new object id: %v", err)
@@ -49,7 +66,14 @@ func CreateRowWithId[TId any, T HasIdSetter[TId]](connector gotabase.Connector,
 // CreateRowWithScan creates a new data in the database.
 // The query is expected to return a row, that will match the provided scanner function.
 func CreateRowWithScan[T any](connector gotabase.Connector, object T, scanner func(row gotabase.Row, object T) error, query string, args ...any) error {
-	row, err := connector.QueryRow(query, args...)
+	return CreateRowWithScanContext(context.Background(), connector, object, scanner, query, args...)
+}
+
+// CreateRowWithScanContext creates a new data in the database.
+// The query is expected to return a row, that will match the provided scanner function.
+// As the query modifies data, it is sent to the primary database of a gotabase.ReplicaSet.
+func CreateRowWithScanContext[T any](ctx context.Context, connector gotabase.Connector, object T, scanner func(row gotabase.Row, object T) error, query string, args ...any) error {
+	row, err := resolveConnector(ctx, connector).QueryRowContext(gotabase.ForcePrimary(ctx), query, args...)
 	if err != nil {
 		return Errors.HandleError(err)
 	}
@@ -63,7 +87,12 @@ func CreateRowWithScan[T any](connector gotabase.Connector, object T, scanner fu
 
 // CreateRow creates a new data in the database.
 func CreateRow(connector gotabase.Connector, query string, args ...any) error {
-	result, err := connector.Exec(query, args...)
+	return CreateRowContext(context.Background(), connector, query, args...)
+}
+
+// CreateRowContext creates a new data in the database.
+func CreateRowContext(ctx context.Context, connector gotabase.Connector, query string, args ...any) error {
+	result, err := resolveConnector(ctx, connector).ExecContext(ctx, query, args...)
 	if err != nil {
 		return Errors.HandleError(err)
 	}
@@ -83,19 +112,37 @@ func CreateRow(connector gotabase.Connector, query string, args ...any) error {
 // UpdateRow runs the query to update a single row in the database.
 // If none or more than one row are updated, then error will be returned.
 func UpdateRow(connector gotabase.Connector, query string, args ...any) error {
-	return runSingleRowAffectedQuery(connector, query, args...)
+	return UpdateRowContext(context.Background(), connector, query, args...)
+}
+
+// UpdateRowContext runs the query to update a single row in the database.
+// If none or more than one row are updated, then error will be returned.
+func UpdateRowContext(ctx context.Context, connector gotabase.Connector, query string, args ...any) error {
+	return runSingleRowAffectedQuery(ctx, connector, query, args...)
 }
 
 // DeleteRow runs the query to remove a single row from the database.
 // If none or more than one row are affected, then error will be returned.
 func DeleteRow(connector gotabase.Connector, query string, args ...any) error {
-	return runSingleRowAffectedQuery(connector, query, args...)
+	return DeleteRowContext(context.Background(), connector, query, args...)
+}
+
+// DeleteRowContext runs the query to remove a single row from the database.
+// If none or more than one row are affected, then error will be returned.
+func DeleteRowContext(ctx context.Context, connector gotabase.Connector, query string, args ...any) error {
+	return runSingleRowAffectedQuery(ctx, connector, query, args...)
 }
 
 // DeleteRows runs the query to remove at last one row from the database.
 // If none or more than one row are affected, then error will be returned.
 func DeleteRows(connector gotabase.Connector, query string, args ...any) error {
-	result, err := connector.Exec(query, args...)
+	return DeleteRowsContext(context.Background(), connector, query, args...)
+}
+
+// DeleteRowsContext runs the query to remove at last one row from the database.
+// If none or more than one row are affected, then error will be returned.
+func DeleteRowsContext(ctx context.Context, connector gotabase.Connector, query string, args ...any) error {
+	result, err := resolveConnector(ctx, connector).ExecContext(ctx, query, args...)
 	if err != nil {
 		return Errors.HandleError(err)
 	}
@@ -114,15 +161,27 @@ func DeleteRows(connector gotabase.Connector, query string, args ...any) error {
 // TryDelete runs the query attempting to delete something.
 // If the query runs successfully, no error is returned, regardless of the number of affected rows.
 func TryDelete(connector gotabase.Connector, query string, args ...any) error {
-	_, err := connector.Exec(query, args...)
+	return TryDeleteContext(context.Background(), connector, query, args...)
+}
+
+// TryDeleteContext runs the query attempting to delete something.
+// If the query runs successfully, no error is returned, regardless of the number of affected rows.
+func TryDeleteContext(ctx context.Context, connector gotabase.Connector, query string, args ...any) error {
+	_, err := resolveConnector(ctx, connector).ExecContext(ctx, query, args...)
 	if err != nil {
 		return Errors.HandleError(err)
 	}
 	return nil
 }
 
-func runSingleRowAffectedQuery(connector gotabase.Connector, query string, args ...any) error {
-	result, err := connector.Exec(query, args...)
+// resolveConnector returns the connector that should run the query, as described in gotabase.Resolve.
+// Connectors not implementing gotabase.ContextConnector are called without the context.
+func resolveConnector(ctx context.Context, connector gotabase.Connector) gotabase.ContextConnector {
+	return gotabase.AsContextConnector(gotabase.Resolve(ctx, connector))
+}
+
+func runSingleRowAffectedQuery(ctx context.Context, connector gotabase.Connector, query string, args ...any) error {
+	result, err := resolveConnector(ctx, connector).ExecContext(ctx, query, args...)
 	if err != nil {
 		return Errors.HandleError(err)
 	}
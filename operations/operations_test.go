@@ -1,10 +1,13 @@
 package operations
 
 import (
+	"context"
+	"errors"
 	"github.com/KowalskiPiotr98/gotabase"
 	"github.com/KowalskiPiotr98/gotabase/internal/tests"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 type testData struct {
@@ -73,6 +76,18 @@ func TestCreateRowWithId(t *testing.T) {
 		err = CreateRowWithId(db, testDataObject, "insert into test (id) values (12) returning id")
 		assert.Equal(t, Errors.DataAlreadyExistErr, err)
 	})
+	t.Run("Replica set, object created on primary", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		replica, err := gotabase.Open("host=invalid.localhost", "postgres", gotabase.WithoutPing())
+		tests.PanicOnErr(err)
+		defer replica.Close()
+		set := gotabase.NewReplicaSet(db, []*gotabase.DB{replica}, gotabase.RoundRobin)
+		testDataObject := &testData{}
+		err = CreateRowWithId(set, testDataObject, "insert into test (id) values (12) returning id")
+		assert.NoError(t, err)
+		assert.Equal(t, 12, testDataObject.Id)
+	})
 }
 
 func TestDeleteRow(t *testing.T) {
@@ -93,3 +108,96 @@ func TestDeleteRow(t *testing.T) {
 		assert.Equal(t, Errors.DataNotFoundErr, err)
 	})
 }
+
+func TestQueryRowsContext(t *testing.T) {
+	t.Run("Rows queried and returned", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		_, err := db.Exec("insert into test(id) values (12)")
+		tests.PanicOnErr(err)
+		rows, err := QueryRowsContext(context.Background(), db, scanTest, "select id from test")
+		assert.NoError(t, err)
+		assert.Len(t, rows, 1)
+	})
+	t.Run("Context cancelled, error returned", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := QueryRowsContext(ctx, db, scanTest, "select id from test")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestUpdateRowContext(t *testing.T) {
+	t.Run("Row updated", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		_, err := db.Exec("insert into test(id) values (12)")
+		tests.PanicOnErr(err)
+		err = UpdateRowContext(context.Background(), db, "update test set id = 13 where id = 12")
+		assert.NoError(t, err)
+	})
+}
+
+func TestTryDeleteContext(t *testing.T) {
+	t.Run("Deadline exceeded, error returned", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := TryDeleteContext(ctx, db, "select pg_sleep(1)")
+		assert.Error(t, err)
+	})
+	t.Run("Connector without context methods, plain method used", func(t *testing.T) {
+		connector := &plainConnector{}
+		assert.NoError(t, TryDeleteContext(context.Background(), connector, "delete from test"))
+		assert.Equal(t, []string{"delete from test"}, connector.executed)
+	})
+}
+
+func TestConnectorFromContext(t *testing.T) {
+	t.Run("Nil connector, transaction from context used", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		tx, err := db.BeginTransaction()
+		tests.PanicOnErr(err)
+		ctx := gotabase.ContextWithConnector(context.Background(), tx)
+		err = CreateRowContext(ctx, nil, "insert into test (id) values (12)")
+		assert.NoError(t, err)
+		tests.PanicOnErr(tx.Rollback())
+		rows, err := QueryRows(db, scanTest, "select id from test")
+		assert.NoError(t, err)
+		assert.Empty(t, rows)
+	})
+	t.Run("Pool connector, transaction of that pool from context used", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		tx, err := db.BeginTransaction()
+		tests.PanicOnErr(err)
+		ctx := gotabase.ContextWithConnector(context.Background(), tx)
+		err = CreateRowContext(ctx, db, "insert into test (id) values (12)")
+		assert.NoError(t, err)
+		tests.PanicOnErr(tx.Rollback())
+		rows, err := QueryRows(db, scanTest, "select id from test")
+		assert.NoError(t, err)
+		assert.Empty(t, rows)
+	})
+}
+
+// plainConnector implements only the methods of gotabase.Connector, as custom implementations written against it do.
+type plainConnector struct {
+	executed []string
+}
+
+func (c *plainConnector) QueryRow(string, ...interface{}) (gotabase.Row, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *plainConnector) QueryRows(string, ...interface{}) (gotabase.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *plainConnector) Exec(sql string, _ ...interface{}) (gotabase.Result, error) {
+	c.executed = append(c.executed, sql)
+	return nil, nil
+}
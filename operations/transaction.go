@@ -0,0 +1,78 @@
+package operations
+
+import (
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"time"
+)
+
+const defaultRetryMaxAttempts = 3
+
+// TransactionRunner is implemented by database handles capable of running a callback in a transaction, such as gotabase.DB.
+type TransactionRunner interface {
+	WithTransactionOptions(opts gotabase.TxOptions, fn func(tx *gotabase.Transaction) error) error
+}
+
+// RetryOption configures RetryTransaction.
+type RetryOption func(config *retryConfig)
+
+type retryConfig struct {
+	maxAttempts int
+	backoff     gotabase.Backoff
+	onRetry     func(attempt int, err error)
+	txOptions   gotabase.TxOptions
+}
+
+// WithMaxAttempts sets the maximum number of times the callback is run. Defaults to 3.
+func WithMaxAttempts(attempts int) RetryOption {
+	return func(config *retryConfig) {
+		config.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the backoff used between attempts.
+func WithBackoff(backoff gotabase.Backoff) RetryOption {
+	return func(config *retryConfig) {
+		config.backoff = backoff
+	}
+}
+
+// WithOnRetry registers a hook called before every retry with the number of the failed attempt and its error.
+func WithOnRetry(onRetry func(attempt int, err error)) RetryOption {
+	return func(config *retryConfig) {
+		config.onRetry = onRetry
+	}
+}
+
+// WithTxOptions sets the options every attempt's transaction is started with, such as the serializable isolation level.
+func WithTxOptions(opts gotabase.TxOptions) RetryOption {
+	return func(config *retryConfig) {
+		config.txOptions = opts
+	}
+}
+
+// RetryTransaction runs fn in a transaction, replaying the whole transaction when it fails with an error recognised by Errors.IsRetryable.
+// Since the callback may run multiple times, it should not have side effects outside the transaction.
+func RetryTransaction(runner TransactionRunner, fn func(tx *gotabase.Transaction) error, opts ...RetryOption) error {
+	config := &retryConfig{
+		maxAttempts: defaultRetryMaxAttempts,
+		backoff:     gotabase.Backoff{InitialInterval: 10 * time.Millisecond, MaxInterval: time.Second, Jitter: 0.5},
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runner.WithTransactionOptions(config.txOptions, fn)
+		if err == nil || attempt >= config.maxAttempts || !Errors.IsRetryable(err) {
+			return err
+		}
+
+		if config.onRetry != nil {
+			config.onRetry(attempt, err)
+		}
+		delay := config.backoff.Delay(attempt)
+		logger.LogWarn("Transaction failed with a retryable error (attempt %d): %v, retrying in %v", attempt, err, delay)
+		time.Sleep(delay)
+	}
+}
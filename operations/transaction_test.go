@@ -0,0 +1,54 @@
+package operations
+
+import (
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type fakeRunner struct {
+	errs  []error
+	calls int
+}
+
+func (r *fakeRunner) WithTransactionOptions(gotabase.TxOptions, func(tx *gotabase.Transaction) error) error {
+	r.calls++
+	if len(r.errs) == 0 {
+		return nil
+	}
+	err := r.errs[0]
+	r.errs = r.errs[1:]
+	return err
+}
+
+func TestRetryTransaction(t *testing.T) {
+	fastBackoff := WithBackoff(gotabase.Backoff{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond})
+
+	t.Run("Serialization failure, transaction retried", func(t *testing.T) {
+		setPostgresHandlers()
+		runner := &fakeRunner{errs: []error{&pq.Error{Code: "40001"}, &pq.Error{Code: "40P01"}}}
+		var retries []int
+		err := RetryTransaction(runner, nil, fastBackoff, WithOnRetry(func(attempt int, err error) {
+			retries = append(retries, attempt)
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, runner.calls)
+		assert.Equal(t, []int{1, 2}, retries)
+	})
+	t.Run("Attempts exhausted, error returned", func(t *testing.T) {
+		runner := &fakeRunner{errs: []error{Errors.DeadlockErr, Errors.DeadlockErr, Errors.DeadlockErr}}
+		err := RetryTransaction(runner, nil, fastBackoff, WithMaxAttempts(2))
+		assert.ErrorIs(t, err, Errors.DeadlockErr)
+		assert.Equal(t, 2, runner.calls)
+	})
+	t.Run("Non-retryable error, returned immediately", func(t *testing.T) {
+		callbackErr := errors.New("callback failed")
+		runner := &fakeRunner{errs: []error{callbackErr}}
+		err := RetryTransaction(runner, nil, fastBackoff)
+		assert.Equal(t, callbackErr, err)
+		assert.Equal(t, 1, runner.calls)
+	})
+}
@@ -0,0 +1,139 @@
+package gotabase
+
+import (
+	"context"
+	"database/sql"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"time"
+)
+
+// Option configures a connection pool created with Open or one of the connection initialisation functions.
+type Option func(config *config)
+
+type config struct {
+	maxOpenConns    *int
+	maxIdleConns    *int
+	connMaxLifetime *time.Duration
+	connMaxIdleTime *time.Duration
+	ping            bool
+	pingTimeout     time.Duration
+	startupRetry    *Backoff
+	startupMaxWait  time.Duration
+	connectHooks    []connectHook
+
+	leakDetectionAge time.Duration
+}
+
+func newConfig(opts []Option) *config {
+	config := &config{
+		ping: true,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// WithMaxOpenConns sets the maximum number of open connections to the database.
+// See sql.DB.SetMaxOpenConns for details.
+func WithMaxOpenConns(n int) Option {
+	return func(config *config) {
+		config.maxOpenConns = &n
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of connections in the idle connection pool.
+// See sql.DB.SetMaxIdleConns for details.
+func WithMaxIdleConns(n int) Option {
+	return func(config *config) {
+		config.maxIdleConns = &n
+	}
+}
+
+// WithConnMaxLifetime sets the maximum amount of time a connection may be reused.
+// See sql.DB.SetConnMaxLifetime for details.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(config *config) {
+		config.connMaxLifetime = &d
+	}
+}
+
+// WithConnMaxIdleTime sets the maximum amount of time a connection may be idle.
+// See sql.DB.SetConnMaxIdleTime for details.
+func WithConnMaxIdleTime(d time.Duration) Option {
+	return func(config *config) {
+		config.connMaxIdleTime = &d
+	}
+}
+
+// WithPingTimeout limits the time the startup ping is allowed to take.
+// By default, the ping is not limited in time.
+func WithPingTimeout(d time.Duration) Option {
+	return func(config *config) {
+		config.pingTimeout = d
+	}
+}
+
+// WithStartupRetry makes the startup ping retry with the provided backoff until it succeeds or maxWait elapses.
+// This is useful when the application may start before the database accepts connections.
+// Each failed attempt is logged using the logger package.
+func WithStartupRetry(backoff Backoff, maxWait time.Duration) Option {
+	return func(config *config) {
+		config.startupRetry = &backoff
+		config.startupMaxWait = maxWait
+	}
+}
+
+// WithoutPing disables the startup ping, so that the connection pool is created without verifying that the database can be reached.
+func WithoutPing() Option {
+	return func(config *config) {
+		config.ping = false
+	}
+}
+
+func (c *config) applyPoolSettings(database *sql.DB) {
+	if c.maxOpenConns != nil {
+		database.SetMaxOpenConns(*c.maxOpenConns)
+	}
+	if c.maxIdleConns != nil {
+		database.SetMaxIdleConns(*c.maxIdleConns)
+	}
+	if c.connMaxLifetime != nil {
+		database.SetConnMaxLifetime(*c.connMaxLifetime)
+	}
+	if c.connMaxIdleTime != nil {
+		database.SetConnMaxIdleTime(*c.connMaxIdleTime)
+	}
+}
+
+func (c *config) pingDatabase(database *sql.DB) error {
+	if !c.ping {
+		return nil
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := c.pingOnce(database)
+		if err == nil || c.startupRetry == nil {
+			return err
+		}
+
+		delay := c.startupRetry.Delay(attempt)
+		if time.Since(start)+delay > c.startupMaxWait {
+			logger.LogWarn("Database is still not ready after %d attempts, giving up: %v", attempt, err)
+			return err
+		}
+		logger.LogWarn("Database is not ready yet (attempt %d): %v, retrying in %v", attempt, err, delay)
+		time.Sleep(delay)
+	}
+}
+
+func (c *config) pingOnce(database *sql.DB) error {
+	ctx := context.Background()
+	if c.pingTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.pingTimeout)
+		defer cancel()
+	}
+	return database.PingContext(ctx)
+}
@@ -0,0 +1,44 @@
+package gotabase
+
+import (
+	"database/sql"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestOpen(t *testing.T) {
+	t.Run("Pool settings applied without ping", func(t *testing.T) {
+		db, err := Open("host=invalid.localhost", "postgres", WithoutPing(), WithMaxOpenConns(7))
+		assert.NoError(t, err)
+		defer db.Close()
+		assert.Equal(t, 7, db.database.Stats().MaxOpenConnections)
+	})
+	t.Run("Unknown driver, error returned", func(t *testing.T) {
+		_, err := Open("", "unknown-driver", WithoutPing())
+		assert.Error(t, err)
+	})
+}
+
+func TestWithStartupRetry(t *testing.T) {
+	t.Run("Ping retried until max wait elapses", func(t *testing.T) {
+		start := time.Now()
+		_, err := Open("host=127.0.0.1 port=1 sslmode=disable", "postgres",
+			WithStartupRetry(Backoff{InitialInterval: 10 * time.Millisecond, MaxInterval: 20 * time.Millisecond}, 100*time.Millisecond))
+		assert.Error(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+}
+
+func TestOpenDB(t *testing.T) {
+	t.Run("Existing pool adopted", func(t *testing.T) {
+		database, err := sql.Open("postgres", "host=invalid.localhost")
+		assert.NoError(t, err)
+		defer database.Close()
+		db, err := OpenDB(database, WithoutPing(), WithMaxOpenConns(3))
+		assert.NoError(t, err)
+		assert.Same(t, database, db.SqlDB())
+		assert.Equal(t, 3, database.Stats().MaxOpenConnections)
+	})
+}
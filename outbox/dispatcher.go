@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"github.com/KowalskiPiotr98/gotabase/operations"
+	"time"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+)
+
+// Publisher delivers a single message to its destination, such as a message broker.
+// Returning an error makes the message retried later.
+type Publisher func(ctx context.Context, message *Message) error
+
+// Dispatcher polls the outbox table and hands pending messages to a Publisher.
+// Messages are locked with FOR UPDATE SKIP LOCKED, so multiple dispatchers can run concurrently without sending the same message twice at the same time.
+// Delivery is at-least-once: a message may be published again if marking it as delivered fails.
+type Dispatcher struct {
+	database     *gotabase.DB
+	publish      Publisher
+	batchSize    int
+	pollInterval time.Duration
+	backoff      gotabase.Backoff
+}
+
+// Option configures a Dispatcher.
+type Option func(dispatcher *Dispatcher)
+
+// WithBatchSize sets the maximum number of messages handled in a single transaction. Defaults to 100.
+func WithBatchSize(batchSize int) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.batchSize = batchSize
+	}
+}
+
+// WithPollInterval sets how long the dispatcher waits before polling again when there are no pending messages. Defaults to 1 second.
+func WithPollInterval(interval time.Duration) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.pollInterval = interval
+	}
+}
+
+// WithBackoff sets the backoff used to delay retries of messages that failed to be published.
+func WithBackoff(backoff gotabase.Backoff) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.backoff = backoff
+	}
+}
+
+// NewDispatcher creates a new dispatcher publishing messages from the outbox table of the provided database.
+func NewDispatcher(database *gotabase.DB, publish Publisher, opts ...Option) *Dispatcher {
+	dispatcher := &Dispatcher{
+		database:     database,
+		publish:      publish,
+		batchSize:    defaultBatchSize,
+		pollInterval: defaultPollInterval,
+		backoff:      gotabase.Backoff{InitialInterval: time.Second, MaxInterval: 10 * time.Minute, Jitter: 0.2},
+	}
+	for _, opt := range opts {
+		opt(dispatcher)
+	}
+	return dispatcher
+}
+
+// Run keeps dispatching messages until the context is done.
+// Errors are logged and do not stop the dispatcher.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		dispatched, err := d.DispatchBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.LogWarn("Failed to dispatch outbox messages: %v", err)
+		}
+		if dispatched == d.batchSize && err == nil {
+			// there may be more pending messages, so don't wait
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+// DispatchBatch publishes a single batch of pending messages and returns the number of messages handled.
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	dispatched := 0
+	err := d.database.WithTransaction(func(tx *gotabase.Transaction) error {
+		messages, err := operations.QueryRowsContext(ctx, tx, scanMessage, SelectPendingSql, d.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if err = d.dispatch(ctx, tx, message); err != nil {
+				return err
+			}
+			dispatched++
+		}
+		return nil
+	})
+	return dispatched, err
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, tx *gotabase.Transaction, message *Message) error {
+	publishErr := d.publish(ctx, message)
+	if publishErr == nil {
+		return operations.UpdateRowContext(ctx, tx, MarkDeliveredSql, message.Id)
+	}
+
+	delay := d.backoff.Delay(message.Attempts + 1)
+	logger.LogWarn("Failed to publish outbox message %d (attempt %d): %v, retrying in %v", message.Id, message.Attempts+1, publishErr, delay)
+	return operations.UpdateRowContext(ctx, tx, MarkFailedSql, message.Id, delay.Milliseconds(), publishErr.Error())
+}
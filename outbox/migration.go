@@ -0,0 +1,56 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KowalskiPiotr98/gotabase/migrations"
+	"io/fs"
+	"time"
+)
+
+var migrationIdTakenErr = errors.New("outbox migration id is already used by another migration")
+
+// WithMigration returns a migration file provider serving the outbox table migration as sql/<id>.sql, in addition to the files of the provided one.
+// The id should be the next free migration number in your own migrations, and must not be changed afterward.
+// Listing the migrations fails if the provided one already contains a migration with that id.
+func WithMigration(fileProvider migrations.MigrationFileProvider, id int) migrations.MigrationFileProvider {
+	return &migrationProvider{MigrationFileProvider: fileProvider, name: fmt.Sprintf("%d.sql", id)}
+}
+
+type migrationProvider struct {
+	migrations.MigrationFileProvider
+	name string
+}
+
+func (p *migrationProvider) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := p.MigrationFileProvider.ReadDir(name)
+	if name != "sql" || err != nil {
+		return entries, err
+	}
+	for _, entry := range entries {
+		if entry.Name() == p.name {
+			return nil, fmt.Errorf("%w: %s", migrationIdTakenErr, p.name)
+		}
+	}
+	return append(entries, migrationEntry{name: p.name}), nil
+}
+
+func (p *migrationProvider) ReadFile(name string) ([]byte, error) {
+	if name == "sql/"+p.name {
+		return []byte(MigrationSql), nil
+	}
+	return p.MigrationFileProvider.ReadFile(name)
+}
+
+type migrationEntry struct {
+	name string
+}
+
+func (e migrationEntry) Name() string               { return e.name }
+func (e migrationEntry) IsDir() bool                { return false }
+func (e migrationEntry) Type() fs.FileMode          { return 0 }
+func (e migrationEntry) Info() (fs.FileInfo, error) { return e, nil }
+func (e migrationEntry) Size() int64                { return int64(len(MigrationSql)) }
+func (e migrationEntry) Mode() fs.FileMode          { return 0444 }
+func (e migrationEntry) ModTime() time.Time         { return time.Time{} }
+func (e migrationEntry) Sys() any                   { return nil }
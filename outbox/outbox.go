@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/operations"
+	"time"
+)
+
+var (
+	// MigrationSql creates the outbox table. It is served by the provider returned from WithMigration.
+	MigrationSql = `create table outbox (
+	id bigserial primary key,
+	topic text not null,
+	payload bytea not null,
+	created_at timestamptz not null default now(),
+	attempts integer not null default 0,
+	next_attempt_at timestamptz not null default now(),
+	delivered_at timestamptz,
+	last_error text
+);
+create index outbox_pending_idx on outbox (next_attempt_at, id) where delivered_at is null;`
+	EnqueueSql       = "insert into outbox (topic, payload) values ($1, $2)"
+	SelectPendingSql = "select id, topic, payload, attempts, created_at from outbox " +
+		"where delivered_at is null and next_attempt_at <= now() " +
+		"order by id limit $1 for update skip locked"
+	MarkDeliveredSql = "update outbox set delivered_at = now() where id = $1"
+	MarkFailedSql    = "update outbox set attempts = attempts + 1, next_attempt_at = now() + $2::double precision * interval '1 millisecond', last_error = $3 where id = $1"
+)
+
+// Message is a single message stored in the outbox.
+type Message struct {
+	Id        int64
+	Topic     string
+	Payload   []byte
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// Enqueue stores a message in the outbox as part of the provided transaction.
+// The message becomes visible to the dispatcher only once the transaction is committed, so it is sent if and only if the rest of the transaction's changes are persisted.
+func Enqueue(tx *gotabase.Transaction, topic string, payload []byte) error {
+	return operations.CreateRow(tx, EnqueueSql, topic, payload)
+}
+
+func scanMessage(row gotabase.Row) (*Message, error) {
+	var message Message
+	if err := row.Scan(&message.Id, &message.Topic, &message.Payload, &message.Attempts, &message.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
@@ -0,0 +1,100 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/internal/tests"
+	"github.com/KowalskiPiotr98/gotabase/migrations"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+var testMigrations = fstest.MapFS{
+	"sql/0.sql": {Data: []byte("create table migrations (id integer primary key);")},
+}
+
+func prepareOutbox(t *testing.T) *gotabase.DB {
+	db := tests.GetDatabaseWithCleanup(t)
+	tests.PanicOnErr(migrations.Migrate(db, WithMigration(testMigrations, 1)))
+	return db
+}
+
+func enqueue(db *gotabase.DB, topic string, payload string) {
+	tests.PanicOnErr(db.WithTransaction(func(tx *gotabase.Transaction) error {
+		return Enqueue(tx, topic, []byte(payload))
+	}))
+}
+
+func TestWithMigration(t *testing.T) {
+	t.Run("Outbox migration added to provided files", func(t *testing.T) {
+		provider := WithMigration(testMigrations, 1)
+		entries, err := provider.ReadDir("sql")
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		body, err := provider.ReadFile("sql/1.sql")
+		assert.NoError(t, err)
+		assert.Equal(t, MigrationSql, string(body))
+		_, err = provider.ReadFile("sql/0.sql")
+		assert.NoError(t, err)
+	})
+	t.Run("Migration id already used, error returned", func(t *testing.T) {
+		provider := WithMigration(testMigrations, 0)
+		_, err := provider.ReadDir("sql")
+		assert.ErrorIs(t, err, migrationIdTakenErr)
+	})
+}
+
+func TestDispatcher_DispatchBatch(t *testing.T) {
+	t.Run("Committed messages published once", func(t *testing.T) {
+		db := prepareOutbox(t)
+		enqueue(db, "orders", "first")
+		enqueue(db, "orders", "second")
+		var published []string
+		dispatcher := NewDispatcher(db, func(ctx context.Context, message *Message) error {
+			published = append(published, string(message.Payload))
+			return nil
+		})
+		dispatched, err := dispatcher.DispatchBatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, dispatched)
+		assert.Equal(t, []string{"first", "second"}, published)
+		dispatched, err = dispatcher.DispatchBatch(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, dispatched)
+	})
+	t.Run("Rolled back message not published", func(t *testing.T) {
+		db := prepareOutbox(t)
+		_ = db.WithTransaction(func(tx *gotabase.Transaction) error {
+			tests.PanicOnErr(Enqueue(tx, "orders", []byte("rolled back")))
+			return errors.New("rollback")
+		})
+		dispatcher := NewDispatcher(db, func(ctx context.Context, message *Message) error {
+			t.Fail()
+			return nil
+		})
+		dispatched, err := dispatcher.DispatchBatch(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, dispatched)
+	})
+	t.Run("Publishing failed, message retried later", func(t *testing.T) {
+		db := prepareOutbox(t)
+		enqueue(db, "orders", "failing")
+		dispatcher := NewDispatcher(db, func(ctx context.Context, message *Message) error {
+			return errors.New("broker unavailable")
+		})
+		dispatched, err := dispatcher.DispatchBatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, dispatched)
+		row, err := db.QueryRow("select attempts, last_error, next_attempt_at > now() from outbox")
+		tests.PanicOnErr(err)
+		var attempts int
+		var lastError string
+		var delayed bool
+		tests.PanicOnErr(row.Scan(&attempts, &lastError, &delayed))
+		assert.Equal(t, 1, attempts)
+		assert.Equal(t, "broker unavailable", lastError)
+		assert.True(t, delayed)
+	})
+}
@@ -0,0 +1,222 @@
+package gotabase
+
+import (
+	"context"
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"sync/atomic"
+)
+
+// ReplicaPolicy decides which replica of a ReplicaSet serves a read query.
+type ReplicaPolicy int
+
+const (
+	// RoundRobin sends subsequent reads to subsequent healthy replicas.
+	RoundRobin ReplicaPolicy = iota
+	// LeastBusy sends reads to the healthy replica with the lowest number of connections in use.
+	LeastBusy
+)
+
+type forcePrimaryKey struct{}
+
+// ReplicaSet is a Connector that splits traffic between a primary database and its read replicas.
+// QueryRow and QueryRows are served by healthy replicas, while Exec and all transactions go to the primary.
+// If no replica is healthy, reads fall back to the primary.
+type ReplicaSet struct {
+	primary  *DB
+	replicas []*replica
+	policy   ReplicaPolicy
+	next     atomic.Uint64
+}
+
+type replica struct {
+	database *DB
+	healthy  atomic.Bool
+}
+
+var _ ContextConnector = (*ReplicaSet)(nil)
+var _ NestedTransactionBeginner = (*ReplicaSet)(nil)
+
+// NewReplicaSet creates a ReplicaSet from already opened database handles.
+// All replicas start as healthy.
+func NewReplicaSet(primary *DB, replicas []*DB, policy ReplicaPolicy) *ReplicaSet {
+	set := &ReplicaSet{
+		primary:  primary,
+		replicas: make([]*replica, 0, len(replicas)),
+		policy:   policy,
+	}
+	for _, database := range replicas {
+		r := &replica{database: database}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+	return set
+}
+
+// ForcePrimary returns a context that makes read queries of a ReplicaSet go to the primary database.
+// This is useful to read data that has just been written, before it reaches the replicas.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+func (r *ReplicaSet) QueryRow(sql string, args ...interface{}) (Row, error) {
+	return r.QueryRowContext(context.Background(), sql, args...)
+}
+
+func (r *ReplicaSet) QueryRows(sql string, args ...interface{}) (Rows, error) {
+	return r.QueryRowsContext(context.Background(), sql, args...)
+}
+
+func (r *ReplicaSet) Exec(sql string, args ...interface{}) (Result, error) {
+	return r.ExecContext(context.Background(), sql, args...)
+}
+
+func (r *ReplicaSet) QueryRowContext(ctx context.Context, sql string, args ...interface{}) (Row, error) {
+	return r.reader(ctx).QueryRowContext(ctx, sql, args...)
+}
+
+func (r *ReplicaSet) QueryRowsContext(ctx context.Context, sql string, args ...interface{}) (Rows, error) {
+	return r.reader(ctx).QueryRowsContext(ctx, sql, args...)
+}
+
+func (r *ReplicaSet) ExecContext(ctx context.Context, sql string, args ...interface{}) (Result, error) {
+	return r.primary.ExecContext(ctx, sql, args...)
+}
+
+// Primary returns the primary database handle.
+func (r *ReplicaSet) Primary() *DB {
+	return r.primary
+}
+
+// BeginTransaction starts a new transaction on the primary database.
+func (r *ReplicaSet) BeginTransaction() (*Transaction, error) {
+	return r.primary.BeginTransaction()
+}
+
+// BeginTransactionWithOptions starts a new transaction with the provided options on the primary database.
+func (r *ReplicaSet) BeginTransactionWithOptions(opts TxOptions) (*Transaction, error) {
+	return r.primary.BeginTransactionWithOptions(opts)
+}
+
+// BeginNested starts a new transaction on the primary database.
+// See the BeginNested function for details.
+func (r *ReplicaSet) BeginNested() (*Transaction, error) {
+	return r.primary.BeginTransaction()
+}
+
+// WithTransaction runs fn in a new transaction on the primary database.
+// See DB.WithTransaction for details.
+func (r *ReplicaSet) WithTransaction(fn func(tx *Transaction) error) error {
+	return r.primary.WithTransaction(fn)
+}
+
+// WithTransactionOptions runs fn in a new transaction with the provided options on the primary database.
+// See DB.WithTransaction for details.
+func (r *ReplicaSet) WithTransactionOptions(opts TxOptions, fn func(tx *Transaction) error) error {
+	return r.primary.WithTransactionOptions(opts, fn)
+}
+
+// Conn pins a single physical connection of the primary database.
+func (r *ReplicaSet) Conn(ctx context.Context) (*Conn, error) {
+	return r.primary.Conn(ctx)
+}
+
+// MarkUnhealthy takes the provided replica out of rotation.
+func (r *ReplicaSet) MarkUnhealthy(database *DB) {
+	r.setHealthy(database, false)
+}
+
+// MarkHealthy puts the provided replica back into rotation.
+func (r *ReplicaSet) MarkHealthy(database *DB) {
+	r.setHealthy(database, true)
+}
+
+// CheckReplicas pings every replica and updates its health accordingly.
+func (r *ReplicaSet) CheckReplicas(ctx context.Context) {
+	for _, replica := range r.replicas {
+		err := replica.database.Ping(ctx)
+		if err != nil && replica.healthy.Load() {
+			logger.LogWarn("Database replica is unhealthy, taking it out of rotation: %v", err)
+		}
+		replica.healthy.Store(err == nil)
+	}
+}
+
+// Shutdown gracefully closes the primary and all replica databases.
+// See DB.Shutdown for details.
+func (r *ReplicaSet) Shutdown(ctx context.Context) error {
+	errs := []error{r.primary.Shutdown(ctx)}
+	for _, replica := range r.replicas {
+		errs = append(errs, replica.database.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the primary and all replica databases.
+func (r *ReplicaSet) Close() error {
+	err := r.primary.Close()
+	for _, replica := range r.replicas {
+		if replicaErr := replica.database.Close(); replicaErr != nil && err == nil {
+			err = replicaErr
+		}
+	}
+	return err
+}
+
+func (r *ReplicaSet) setHealthy(database *DB, healthy bool) {
+	for _, replica := range r.replicas {
+		if replica.database == database {
+			replica.healthy.Store(healthy)
+		}
+	}
+}
+
+func (r *ReplicaSet) reader(ctx context.Context) *DB {
+	if forced, _ := ctx.Value(forcePrimaryKey{}).(bool); forced {
+		return r.primary
+	}
+
+	var selected *replica
+	switch r.policy {
+	case LeastBusy:
+		selected = r.leastBusyReplica()
+	default:
+		selected = r.nextReplica()
+	}
+	if selected == nil {
+		return r.primary
+	}
+	return selected.database
+}
+
+func (r *ReplicaSet) nextReplica() *replica {
+	count := uint64(len(r.replicas))
+	for i := uint64(0); i < count; i++ {
+		candidate := r.replicas[r.next.Add(1)%count]
+		if candidate.healthy.Load() {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// leastBusyReplica returns the healthy replica with the fewest connections in use.
+// The scan starts at a rotating offset, so that ties are broken in a round-robin fashion.
+func (r *ReplicaSet) leastBusyReplica() *replica {
+	var selected *replica
+	selectedInUse := 0
+	count := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < count; i++ {
+		candidate := r.replicas[(start+i)%count]
+		if !candidate.healthy.Load() {
+			continue
+		}
+		inUse := candidate.database.Stats().InUse
+		if selected == nil || inUse < selectedInUse {
+			selected = candidate
+			selectedInUse = inUse
+		}
+	}
+	return selected
+}
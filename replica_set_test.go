@@ -0,0 +1,55 @@
+package gotabase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func openUnpinged(t *testing.T) *DB {
+	db, err := Open("host=invalid.localhost", "postgres", WithoutPing())
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestReplicaSet_reader(t *testing.T) {
+	t.Run("Round robin rotates replicas", func(t *testing.T) {
+		primary, first, second := openUnpinged(t), openUnpinged(t), openUnpinged(t)
+		set := NewReplicaSet(primary, []*DB{first, second}, RoundRobin)
+		ctx := context.Background()
+		selected := []*DB{set.reader(ctx), set.reader(ctx), set.reader(ctx)}
+		assert.NotSame(t, selected[0], selected[1])
+		assert.Same(t, selected[0], selected[2])
+		assert.NotContains(t, selected, primary)
+	})
+	t.Run("Least busy replicas tied, replicas rotated", func(t *testing.T) {
+		primary, first, second := openUnpinged(t), openUnpinged(t), openUnpinged(t)
+		set := NewReplicaSet(primary, []*DB{first, second}, LeastBusy)
+		ctx := context.Background()
+		selected := []*DB{set.reader(ctx), set.reader(ctx), set.reader(ctx)}
+		assert.NotSame(t, selected[0], selected[1])
+		assert.Same(t, selected[0], selected[2])
+		assert.NotContains(t, selected, primary)
+	})
+	t.Run("Unhealthy replica skipped", func(t *testing.T) {
+		primary, first, second := openUnpinged(t), openUnpinged(t), openUnpinged(t)
+		set := NewReplicaSet(primary, []*DB{first, second}, RoundRobin)
+		set.MarkUnhealthy(first)
+		assert.Same(t, second, set.reader(context.Background()))
+		assert.Same(t, second, set.reader(context.Background()))
+	})
+	t.Run("No healthy replicas, primary used", func(t *testing.T) {
+		primary, first := openUnpinged(t), openUnpinged(t)
+		set := NewReplicaSet(primary, []*DB{first}, LeastBusy)
+		set.MarkUnhealthy(first)
+		assert.Same(t, primary, set.reader(context.Background()))
+		set.MarkHealthy(first)
+		assert.Same(t, first, set.reader(context.Background()))
+	})
+	t.Run("Primary forced, primary used", func(t *testing.T) {
+		primary, first := openUnpinged(t), openUnpinged(t)
+		set := NewReplicaSet(primary, []*DB{first}, RoundRobin)
+		assert.Same(t, primary, set.reader(ForcePrimary(context.Background())))
+	})
+}
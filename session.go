@@ -0,0 +1,126 @@
+package gotabase
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"io"
+)
+
+var connectHooksUnsupportedErr = errors.New("connect hooks can't be applied to an already opened connection pool")
+
+// SessionConn allows executing statements on a freshly opened physical connection, before it is handed to the pool.
+type SessionConn interface {
+	ExecContext(ctx context.Context, query string) error
+}
+
+// ConnectHook is run on every new physical connection of the pool.
+type ConnectHook func(ctx context.Context, conn SessionConn) error
+
+type connectHook struct {
+	hook        ConnectHook
+	failOnError bool
+}
+
+// WithOnConnect registers a hook run on every new physical connection of the pool, such as setting the search path or the time zone.
+// If failOnError is set, a connection for which the hook fails is discarded and the error is returned to the caller.
+// Otherwise, the failure is only logged and the connection is used as is.
+// Hooks are not supported by OpenDB and InitialiseFromDB, as the pool has already been created at that point.
+func WithOnConnect(hook ConnectHook, failOnError bool) Option {
+	return func(config *config) {
+		config.connectHooks = append(config.connectHooks, connectHook{hook: hook, failOnError: failOnError})
+	}
+}
+
+// WithSessionStatements runs the provided statements on every new physical connection of the pool.
+// A connection on which any of the statements fails is discarded.
+func WithSessionStatements(statements ...string) Option {
+	return WithOnConnect(func(ctx context.Context, conn SessionConn) error {
+		for _, statement := range statements {
+			if err := conn.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, true)
+}
+
+// hookConnector runs the connect hooks on every connection created by the wrapped connector.
+type hookConnector struct {
+	driver.Connector
+	hooks []connectHook
+}
+
+func (c *hookConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	session := sessionConn{conn: conn}
+	for _, hook := range c.hooks {
+		if err = hook.hook(ctx, session); err != nil {
+			if hook.failOnError {
+				logger.LogWarn("Connect hook failed, discarding connection: %v", err)
+				conn.Close()
+				return nil, err
+			}
+			logger.LogWarn("Connect hook failed: %v", err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *hookConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// dsnConnector is used for drivers that don't implement driver.DriverContext.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c *dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type sessionConn struct {
+	conn driver.Conn
+}
+
+func (s sessionConn) ExecContext(ctx context.Context, query string) error {
+	if execer, ok := s.conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if !errors.Is(err, driver.ErrSkip) {
+			return err
+		}
+	}
+
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := s.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = s.conn.Prepare(query)
+	}
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if stmtExecer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = stmtExecer.ExecContext(ctx, nil)
+	} else {
+		_, err = stmt.Exec(nil)
+	}
+	return err
+}
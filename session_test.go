@@ -0,0 +1,111 @@
+package gotabase
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"sync"
+	"testing"
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("not supported")
+}
+
+type fakeConnector struct {
+	mutex    sync.Mutex
+	executed []string
+	failOn   string
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{connector: c}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeConn struct {
+	connector *fakeConnector
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.connector.mutex.Lock()
+	defer c.connector.mutex.Unlock()
+	if query == c.connector.failOn {
+		return nil, errors.New("statement failed")
+	}
+	c.connector.executed = append(c.connector.executed, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+// fakeRows returns a single row with a single value of 1.
+type fakeRows struct {
+	read bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestWithOnConnect(t *testing.T) {
+	t.Run("Session statements run on new connection", func(t *testing.T) {
+		connector := &fakeConnector{}
+		db, err := OpenConnector(connector, WithSessionStatements("set search_path to app", "set time zone 'UTC'"))
+		assert.NoError(t, err)
+		defer db.Close()
+		assert.Equal(t, []string{"set search_path to app", "set time zone 'UTC'"}, connector.executed)
+	})
+	t.Run("Required hook failed, connection discarded", func(t *testing.T) {
+		connector := &fakeConnector{failOn: "set search_path to app"}
+		_, err := OpenConnector(connector, WithSessionStatements("set search_path to app"))
+		assert.Error(t, err)
+	})
+	t.Run("Optional hook failed, connection used", func(t *testing.T) {
+		connector := &fakeConnector{failOn: "set search_path to app"}
+		db, err := OpenConnector(connector, WithOnConnect(func(ctx context.Context, conn SessionConn) error {
+			return conn.ExecContext(ctx, "set search_path to app")
+		}, false))
+		assert.NoError(t, err)
+		defer db.Close()
+	})
+	t.Run("Existing pool, hooks rejected", func(t *testing.T) {
+		database := openUnpinged(t).SqlDB()
+		_, err := OpenDB(database, WithSessionStatements("set search_path to app"))
+		assert.Equal(t, connectHooksUnsupportedErr, err)
+	})
+}
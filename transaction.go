@@ -1,34 +1,356 @@
 package gotabase
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/KowalskiPiotr98/gotabase/logger"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
 
+var (
+	// SavepointSql creates a savepoint with the provided name.
+	SavepointSql = "savepoint %s"
+	// ReleaseSavepointSql releases a savepoint with the provided name.
+	ReleaseSavepointSql = "release savepoint %s"
+	// RollbackToSavepointSql rolls back to a savepoint with the provided name.
+	RollbackToSavepointSql = "rollback to savepoint %s"
+)
+
+var (
+	nestedTransactionUnsupportedErr = errors.New("connector does not support nested transactions")
+	transactionTimedOutErr          = fmt.Errorf("transaction exceeded its timeout and has been rolled back: %w", context.DeadlineExceeded)
+)
+
+// TxState describes the lifecycle state of a Transaction.
+type TxState int32
+
+const (
+	// TxActive means that the transaction can still be used.
+	TxActive TxState = iota
+	// TxCommitted means that the transaction (or savepoint) has been committed.
+	TxCommitted
+	// TxRolledBack means that the transaction (or savepoint) has been rolled back.
+	TxRolledBack
+
+	// txTimedOut is reported as TxRolledBack, but lets the transaction return a more specific error.
+	txTimedOut TxState = -1
+)
+
+func (s TxState) String() string {
+	switch s {
+	case TxActive:
+		return "active"
+	case TxCommitted:
+		return "committed"
+	case TxRolledBack:
+		return "rolled back"
+	default:
+		return fmt.Sprintf("unknown (%d)", int32(s))
+	}
+}
+
+// NestedTransactionBeginner is implemented by connectors that can start a nested unit of work.
+type NestedTransactionBeginner interface {
+	BeginNested() (*Transaction, error)
+}
+
+// Transaction is a Connector running all its queries in a single database transaction.
+// A Transaction created with BeginNested on another Transaction is backed by a savepoint of its parent instead.
 type Transaction struct {
-	tx *sql.Tx
+	tx       *sql.Tx
+	finished func()
+	// database is the handle the transaction was begun on, if any.
+	database *DB
+
+	parent     *Transaction
+	savepoint  string
+	savepoints atomic.Int64
+	state      atomic.Int32
+
+	callbacksMutex sync.Mutex
+	callbacks      []transactionCallback
+}
+
+type transactionCallback struct {
+	scope    *Transaction
+	onCommit bool
+	fn       func()
 }
 
-func newTransaction(tx *sql.Tx) *Transaction {
-	return &Transaction{tx: tx}
+func newTransaction(tx *sql.Tx, finished func()) *Transaction {
+	return &Transaction{tx: tx, finished: sync.OnceFunc(finished)}
 }
 
-var _ Connector = (*Transaction)(nil)
+var _ ContextConnector = (*Transaction)(nil)
+var _ NestedTransactionBeginner = (*Transaction)(nil)
+
+// BeginNested starts a nested unit of work from the provided connector, without the need to check its concrete type.
+// For a database handle, this starts a new transaction, while for a Transaction a savepoint is created.
+func BeginNested(connector Connector) (*Transaction, error) {
+	beginner, ok := connector.(NestedTransactionBeginner)
+	if !ok {
+		return nil, nestedTransactionUnsupportedErr
+	}
+	return beginner.BeginNested()
+}
+
+// WithNestedTransaction runs fn in a nested unit of work started from the provided connector.
+// See BeginNested and DB.WithTransaction for details.
+func WithNestedTransaction(connector Connector, fn func(tx *Transaction) error) error {
+	tx, err := BeginNested(connector)
+	if err != nil {
+		return err
+	}
+	return runInTransaction(tx, fn)
+}
 
 func (t *Transaction) QueryRow(sql string, args ...interface{}) (Row, error) {
-	row := t.tx.QueryRow(sql, args...)
-	return row, nil
+	return t.QueryRowContext(context.Background(), sql, args...)
 }
 
 func (t *Transaction) QueryRows(sql string, args ...interface{}) (Rows, error) {
-	return t.tx.Query(sql, args...)
+	return t.QueryRowsContext(context.Background(), sql, args...)
 }
 
 func (t *Transaction) Exec(sql string, args ...interface{}) (Result, error) {
-	return t.tx.Exec(sql, args...)
+	return t.ExecContext(context.Background(), sql, args...)
+}
+
+func (t *Transaction) QueryRowContext(ctx context.Context, sql string, args ...interface{}) (Row, error) {
+	if err := t.checkActive(); err != nil {
+		return nil, err
+	}
+
+	row := t.tx.QueryRowContext(ctx, sql, args...)
+	return row, nil
+}
+
+func (t *Transaction) QueryRowsContext(ctx context.Context, sql string, args ...interface{}) (Rows, error) {
+	if err := t.checkActive(); err != nil {
+		return nil, err
+	}
+
+	return t.tx.QueryContext(ctx, sql, args...)
+}
+
+func (t *Transaction) ExecContext(ctx context.Context, sql string, args ...interface{}) (Result, error) {
+	if err := t.checkActive(); err != nil {
+		return nil, err
+	}
+
+	return t.tx.ExecContext(ctx, sql, args...)
+}
+
+// State returns the effective lifecycle state of the transaction.
+// A savepoint is reported as rolled back if any transaction it is nested in has been rolled back, and as committed if any of them has been committed.
+func (t *Transaction) State() TxState {
+	state := TxActive
+	for current := t; current != nil; current = current.parent {
+		switch current.ownState() {
+		case TxRolledBack:
+			return TxRolledBack
+		case TxCommitted:
+			state = TxCommitted
+		}
+	}
+	return state
+}
+
+// ownState returns the state of this transaction, without taking the transactions it is nested in into account.
+func (t *Transaction) ownState() TxState {
+	state := TxState(t.state.Load())
+	if state == txTimedOut {
+		return TxRolledBack
+	}
+	return state
+}
+
+// BeginNested creates a savepoint in this transaction.
+// Committing the returned Transaction releases the savepoint, while rolling it back rolls back to the savepoint.
+func (t *Transaction) BeginNested() (*Transaction, error) {
+	root := t.root()
+	savepoint := fmt.Sprintf("gotabase_savepoint_%d", root.savepoints.Add(1))
+	if _, err := t.Exec(fmt.Sprintf(SavepointSql, savepoint)); err != nil {
+		logger.LogWarn("Failed to create savepoint: %v", err)
+		return nil, err
+	}
+	return &Transaction{tx: t.tx, finished: func() {}, parent: t, savepoint: savepoint}, nil
+}
+
+// OnCommit registers a callback run after the outermost transaction is successfully committed.
+// Callbacks are run in the order of registration.
+// Callbacks registered on a savepoint are discarded if that savepoint is rolled back.
+func (t *Transaction) OnCommit(fn func()) {
+	t.root().addCallback(transactionCallback{scope: t, onCommit: true, fn: fn})
+}
+
+// OnRollback registers a callback run after the outermost transaction is successfully rolled back, or after its commit fails.
+// Callbacks are run in the order of registration.
+func (t *Transaction) OnRollback(fn func()) {
+	t.root().addCallback(transactionCallback{scope: t, onCommit: false, fn: fn})
 }
 
 func (t *Transaction) Commit() error {
-	return t.tx.Commit()
+	if err := t.finish(TxCommitted); err != nil {
+		return err
+	}
+
+	if t.savepoint != "" {
+		_, err := t.tx.Exec(fmt.Sprintf(ReleaseSavepointSql, t.savepoint))
+		return err
+	}
+
+	defer t.finished()
+	if err := t.tx.Commit(); err != nil {
+		// a failed commit leaves nothing to commit or roll back anymore, as the database has discarded the transaction
+		t.state.Store(int32(TxRolledBack))
+		t.runCallbacks(false)
+		return err
+	}
+	t.runCallbacks(true)
+	return nil
 }
 
 func (t *Transaction) Rollback() error {
-	return t.tx.Rollback()
+	if err := t.finish(TxRolledBack); err != nil {
+		return err
+	}
+
+	if t.savepoint != "" {
+		if _, err := t.tx.Exec(fmt.Sprintf(RollbackToSavepointSql, t.savepoint)); err != nil {
+			return err
+		}
+		t.root().discardCommitCallbacks(t)
+		_, err := t.tx.Exec(fmt.Sprintf(ReleaseSavepointSql, t.savepoint))
+		return err
+	}
+
+	defer t.finished()
+	if err := t.tx.Rollback(); err != nil {
+		return err
+	}
+	t.runCallbacks(false)
+	return nil
+}
+
+// finish moves the transaction from the active state to the provided one.
+func (t *Transaction) finish(state TxState) error {
+	if err := t.checkActive(); err != nil {
+		return err
+	}
+	if !t.state.CompareAndSwap(int32(TxActive), int32(state)) {
+		return t.finishedErr()
+	}
+	return nil
+}
+
+// checkActive returns an error if this transaction, or any transaction it is nested in, has already finished.
+func (t *Transaction) checkActive() error {
+	for current := t; current != nil; current = current.parent {
+		if current.ownState() != TxActive {
+			return current.finishedErr()
+		}
+	}
+	return nil
+}
+
+func (t *Transaction) finishedErr() error {
+	if TxState(t.state.Load()) == txTimedOut {
+		return fmt.Errorf("%w: %w", transactionTimedOutErr, sql.ErrTxDone)
+	}
+	return fmt.Errorf("transaction has already been %s: %w", t.ownState(), sql.ErrTxDone)
+}
+
+// expire rolls the transaction back after its timeout.
+// The rollback waits for queries still running in the transaction, and the transaction is only reported as finished once it is done.
+func (t *Transaction) expire() {
+	if !t.state.CompareAndSwap(int32(TxActive), int32(txTimedOut)) {
+		return
+	}
+
+	defer t.finished()
+	if err := t.tx.Rollback(); err != nil {
+		logger.LogWarn("Failed to roll back transaction after its timeout: %v", err)
+		return
+	}
+	logger.LogWarn("Transaction exceeded its timeout and has been rolled back")
+	t.runCallbacks(false)
+}
+
+func (t *Transaction) addCallback(callback transactionCallback) {
+	t.callbacksMutex.Lock()
+	defer t.callbacksMutex.Unlock()
+	t.callbacks = append(t.callbacks, callback)
+}
+
+func (t *Transaction) discardCommitCallbacks(scope *Transaction) {
+	t.callbacksMutex.Lock()
+	defer t.callbacksMutex.Unlock()
+	t.callbacks = slices.DeleteFunc(t.callbacks, func(callback transactionCallback) bool {
+		return callback.onCommit && callback.scope.isWithin(scope)
+	})
+}
+
+func (t *Transaction) runCallbacks(onCommit bool) {
+	t.callbacksMutex.Lock()
+	callbacks := t.callbacks
+	t.callbacks = nil
+	t.callbacksMutex.Unlock()
+
+	for _, callback := range callbacks {
+		if callback.onCommit == onCommit {
+			callback.fn()
+		}
+	}
+}
+
+func (t *Transaction) isWithin(scope *Transaction) bool {
+	for current := t; current != nil; current = current.parent {
+		if current == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Transaction) root() *Transaction {
+	root := t
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// runInTransaction calls fn and commits the transaction if it returns nil.
+// The transaction is rolled back if fn returns an error, panics or exits its goroutine (as with runtime.Goexit).
+// A panic is propagated after the rollback.
+func runInTransaction(tx *Transaction, fn func(tx *Transaction) error) (err error) {
+	returned := false
+	defer func() {
+		if returned {
+			return
+		}
+		recovered := recover()
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logger.LogWarn("Failed to roll back transaction after fn did not return: %v", rollbackErr)
+		}
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+
+	err = fn(tx)
+	returned = true
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logger.LogWarn("Failed to roll back transaction: %v", rollbackErr)
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,290 @@
+package gotabase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/KowalskiPiotr98/gotabase"
+	"github.com/KowalskiPiotr98/gotabase/internal/tests"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func makeTestTable(connector gotabase.Connector) {
+	_, err := connector.Exec("create table test (id integer primary key)")
+	tests.PanicOnErr(err)
+}
+
+func countTestRows(connector gotabase.Connector) int {
+	row, err := connector.QueryRow("select count(*) from test")
+	tests.PanicOnErr(err)
+	var count int
+	tests.PanicOnErr(row.Scan(&count))
+	return count
+}
+
+func TestDB_WithTransaction(t *testing.T) {
+	t.Run("Callback succeeded, transaction committed", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		err := db.WithTransaction(func(tx *gotabase.Transaction) error {
+			_, err := tx.Exec("insert into test (id) values (1)")
+			return err
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, countTestRows(db))
+	})
+	t.Run("Callback failed, transaction rolled back", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		callbackErr := errors.New("callback failed")
+		err := db.WithTransaction(func(tx *gotabase.Transaction) error {
+			_, err := tx.Exec("insert into test (id) values (1)")
+			tests.PanicOnErr(err)
+			return callbackErr
+		})
+		assert.ErrorIs(t, err, callbackErr)
+		assert.Equal(t, 0, countTestRows(db))
+	})
+	t.Run("Callback panicked, transaction rolled back and panic propagated", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		assert.PanicsWithValue(t, "callback panicked", func() {
+			_ = db.WithTransaction(func(tx *gotabase.Transaction) error {
+				_, err := tx.Exec("insert into test (id) values (1)")
+				tests.PanicOnErr(err)
+				panic("callback panicked")
+			})
+		})
+		assert.Equal(t, 0, countTestRows(db))
+	})
+	t.Run("Callback exited goroutine, transaction rolled back", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		var transaction *gotabase.Transaction
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = db.WithTransaction(func(tx *gotabase.Transaction) error {
+				transaction = tx
+				runtime.Goexit()
+				return nil
+			})
+		}()
+		<-done
+		assert.Equal(t, gotabase.TxRolledBack, transaction.State())
+	})
+}
+
+func TestDB_BeginTransactionWithOptions(t *testing.T) {
+	t.Run("Read only transaction, modifications rejected", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		tx, err := db.BeginTransactionWithOptions(gotabase.TxOptions{ReadOnly: true})
+		tests.PanicOnErr(err)
+		defer tx.Rollback()
+		_, err = tx.Exec("insert into test (id) values (1)")
+		assert.Error(t, err)
+	})
+	t.Run("Serializable deferrable transaction, options applied", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tx, err := db.BeginTransactionWithOptions(gotabase.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true, Deferrable: true})
+		tests.PanicOnErr(err)
+		defer tx.Rollback()
+		row, err := tx.QueryRow("select current_setting('transaction_isolation'), current_setting('transaction_deferrable')")
+		tests.PanicOnErr(err)
+		var isolation, deferrable string
+		tests.PanicOnErr(row.Scan(&isolation, &deferrable))
+		assert.Equal(t, "serializable", isolation)
+		assert.Equal(t, "on", deferrable)
+	})
+}
+
+func TestBeginNested(t *testing.T) {
+	t.Run("Database handle, transaction started", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		tx, err := gotabase.BeginNested(db)
+		tests.PanicOnErr(err)
+		_, err = tx.Exec("insert into test (id) values (1)")
+		tests.PanicOnErr(err)
+		assert.NoError(t, tx.Commit())
+		assert.Equal(t, 1, countTestRows(db))
+	})
+	t.Run("Savepoint rolled back, outer transaction kept", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		err := db.WithTransaction(func(tx *gotabase.Transaction) error {
+			_, err := tx.Exec("insert into test (id) values (1)")
+			tests.PanicOnErr(err)
+			nestedErr := gotabase.WithNestedTransaction(tx, func(nested *gotabase.Transaction) error {
+				_, err := nested.Exec("insert into test (id) values (1)")
+				return err
+			})
+			assert.Error(t, nestedErr)
+			_, err = tx.Exec("insert into test (id) values (2)")
+			return err
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, countTestRows(db))
+	})
+	t.Run("Savepoint released, changes committed with outer transaction", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		tx, err := db.BeginTransaction()
+		tests.PanicOnErr(err)
+		nested, err := gotabase.BeginNested(tx)
+		tests.PanicOnErr(err)
+		_, err = nested.Exec("insert into test (id) values (1)")
+		tests.PanicOnErr(err)
+		assert.NoError(t, nested.Commit())
+		assert.ErrorIs(t, nested.Rollback(), sql.ErrTxDone)
+		assert.NoError(t, tx.Commit())
+		assert.Equal(t, 1, countTestRows(db))
+	})
+}
+
+func TestTransaction_Callbacks(t *testing.T) {
+	t.Run("Transaction committed, commit callbacks run in order", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		var called []string
+		err := db.WithTransaction(func(tx *gotabase.Transaction) error {
+			tx.OnCommit(func() { called = append(called, "first") })
+			tx.OnRollback(func() { called = append(called, "rollback") })
+			tx.OnCommit(func() { called = append(called, "second") })
+			assert.Empty(t, called)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, called)
+	})
+	t.Run("Transaction rolled back, rollback callbacks run", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		var called []string
+		callbackErr := errors.New("callback failed")
+		err := db.WithTransaction(func(tx *gotabase.Transaction) error {
+			tx.OnCommit(func() { called = append(called, "commit") })
+			tx.OnRollback(func() { called = append(called, "rollback") })
+			return callbackErr
+		})
+		assert.ErrorIs(t, err, callbackErr)
+		assert.Equal(t, []string{"rollback"}, called)
+	})
+	t.Run("Savepoint callbacks, attached to outermost transaction", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		var called []string
+		err := db.WithTransaction(func(tx *gotabase.Transaction) error {
+			_ = gotabase.WithNestedTransaction(tx, func(nested *gotabase.Transaction) error {
+				nested.OnCommit(func() { called = append(called, "released") })
+				return nil
+			})
+			_ = gotabase.WithNestedTransaction(tx, func(nested *gotabase.Transaction) error {
+				nested.OnCommit(func() { called = append(called, "rolled back") })
+				return errors.New("nested failed")
+			})
+			assert.Empty(t, called)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"released"}, called)
+	})
+	t.Run("Commit failed, rollback callbacks run", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		_, err := db.Exec("create table deferred (id integer unique deferrable initially deferred)")
+		tests.PanicOnErr(err)
+		var called []string
+		err = db.WithTransaction(func(tx *gotabase.Transaction) error {
+			tx.OnCommit(func() { called = append(called, "commit") })
+			tx.OnRollback(func() { called = append(called, "rollback") })
+			_, err := tx.Exec("insert into deferred (id) values (1), (1)")
+			return err
+		})
+		assert.Error(t, err)
+		assert.Equal(t, []string{"rollback"}, called)
+	})
+}
+
+func TestTransaction_State(t *testing.T) {
+	t.Run("Transaction committed, further use rejected", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tx, err := db.BeginTransaction()
+		tests.PanicOnErr(err)
+		assert.Equal(t, gotabase.TxActive, tx.State())
+		assert.NoError(t, tx.Commit())
+		assert.Equal(t, gotabase.TxCommitted, tx.State())
+		_, err = tx.Exec("select 1")
+		assert.ErrorIs(t, err, sql.ErrTxDone)
+		assert.ErrorIs(t, tx.Rollback(), sql.ErrTxDone)
+	})
+	t.Run("Outer transaction rolled back, savepoint unusable", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tx, err := db.BeginTransaction()
+		tests.PanicOnErr(err)
+		nested, err := tx.BeginNested()
+		tests.PanicOnErr(err)
+		assert.NoError(t, tx.Rollback())
+		assert.Equal(t, gotabase.TxRolledBack, tx.State())
+		assert.Equal(t, gotabase.TxRolledBack, nested.State())
+		_, err = nested.Exec("select 1")
+		assert.ErrorIs(t, err, sql.ErrTxDone)
+	})
+	t.Run("Outer transaction committed, savepoint reported committed", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tx, err := db.BeginTransaction()
+		tests.PanicOnErr(err)
+		nested, err := tx.BeginNested()
+		tests.PanicOnErr(err)
+		assert.NoError(t, tx.Commit())
+		assert.Equal(t, gotabase.TxCommitted, nested.State())
+		assert.ErrorIs(t, nested.Rollback(), sql.ErrTxDone)
+	})
+}
+
+func TestTransaction_Timeout(t *testing.T) {
+	t.Run("Timeout exceeded, transaction rolled back", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		makeTestTable(db)
+		tx, err := db.BeginTransactionWithOptions(gotabase.TxOptions{Timeout: 50 * time.Millisecond})
+		tests.PanicOnErr(err)
+		rolledBack := make(chan struct{})
+		tx.OnRollback(func() { close(rolledBack) })
+		_, err = tx.Exec("insert into test (id) values (1)")
+		tests.PanicOnErr(err)
+		<-rolledBack
+		assert.Equal(t, gotabase.TxRolledBack, tx.State())
+		_, err = tx.Exec("insert into test (id) values (2)")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorIs(t, tx.Commit(), sql.ErrTxDone)
+		assert.Equal(t, 0, countTestRows(db))
+	})
+	t.Run("Timeout exceeded, callbacks run after rollback", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tx, err := db.BeginTransactionWithOptions(gotabase.TxOptions{Timeout: 50 * time.Millisecond})
+		tests.PanicOnErr(err)
+		_, err = tx.Exec("select pg_advisory_xact_lock(7)")
+		tests.PanicOnErr(err)
+		released := make(chan bool)
+		tx.OnRollback(func() {
+			row, err := db.QueryRow("select pg_try_advisory_xact_lock(7)")
+			tests.PanicOnErr(err)
+			var acquired bool
+			tests.PanicOnErr(row.Scan(&acquired))
+			released <- acquired
+		})
+		assert.True(t, <-released)
+	})
+	t.Run("Transaction local timeouts, set for transaction only", func(t *testing.T) {
+		db := tests.GetDatabaseWithCleanup(t)
+		tx, err := db.BeginTransactionWithOptions(gotabase.TxOptions{IdleInTransactionTimeout: time.Second, StatementTimeout: 50 * time.Millisecond})
+		tests.PanicOnErr(err)
+		defer tx.Rollback()
+		row, err := tx.QueryRow("select current_setting('idle_in_transaction_session_timeout')")
+		tests.PanicOnErr(err)
+		var idleTimeout string
+		tests.PanicOnErr(row.Scan(&idleTimeout))
+		assert.Equal(t, "1s", idleTimeout)
+		_, err = tx.Exec("select pg_sleep(1)")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,55 @@
+package gotabase
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+var (
+	// DeferrableTransactionSql is executed at the start of transactions begun with TxOptions.Deferrable set.
+	DeferrableTransactionSql = "set transaction deferrable"
+	// IdleInTransactionTimeoutSql sets the idle timeout, in milliseconds, of transactions begun with TxOptions.IdleInTransactionTimeout set.
+	IdleInTransactionTimeoutSql = "set local idle_in_transaction_session_timeout = %d"
+	// StatementTimeoutSql sets the statement timeout, in milliseconds, of transactions begun with TxOptions.StatementTimeout set.
+	StatementTimeoutSql = "set local statement_timeout = %d"
+)
+
+// TxOptions describes how a transaction should be started.
+type TxOptions struct {
+	// Isolation is the isolation level of the transaction. The zero value uses the default level of the database.
+	Isolation sql.IsolationLevel
+	// ReadOnly makes the transaction reject any data modifications.
+	ReadOnly bool
+	// Deferrable makes a serializable, read-only transaction wait for a snapshot that can't cause serialisation failures.
+	// This is a Postgres feature, executed using DeferrableTransactionSql.
+	Deferrable bool
+	// Timeout is the maximum duration of the transaction, after which it is rolled back automatically and any further use returns an error.
+	// The zero value means no limit.
+	Timeout time.Duration
+	// IdleInTransactionTimeout makes the database terminate the session if the transaction stays idle for longer than this.
+	// This is set for the transaction only, using IdleInTransactionTimeoutSql. The zero value keeps the session setting.
+	IdleInTransactionTimeout time.Duration
+	// StatementTimeout makes the database abort statements of this transaction running for longer than this.
+	// This is set for the transaction only, using StatementTimeoutSql. The zero value keeps the session setting.
+	StatementTimeout time.Duration
+}
+
+func (o TxOptions) sqlOptions() *sql.TxOptions {
+	return &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly}
+}
+
+// setupStatements returns the statements that need to be executed at the start of the transaction to apply the options.
+func (o TxOptions) setupStatements() []string {
+	statements := make([]string, 0)
+	if o.Deferrable {
+		statements = append(statements, DeferrableTransactionSql)
+	}
+	if o.IdleInTransactionTimeout > 0 {
+		statements = append(statements, fmt.Sprintf(IdleInTransactionTimeoutSql, o.IdleInTransactionTimeout.Milliseconds()))
+	}
+	if o.StatementTimeout > 0 {
+		statements = append(statements, fmt.Sprintf(StatementTimeoutSql, o.StatementTimeout.Milliseconds()))
+	}
+	return statements
+}